@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-server/migrations"
+	"go-server/models"
+	"go-server/services"
+
+	"gorm.io/gorm"
+)
+
+// runCommand executes a management subcommand such as "apikey create"
+// instead of starting the server
+func runCommand(db *gorm.DB, args []string) error {
+	switch {
+	case len(args) >= 2 && args[0] == "apikey" && args[1] == "create":
+		return createAPIKey(db, args[2:])
+	case len(args) >= 2 && args[0] == "migrate":
+		return migrate(db, args[1], args[2:])
+	case len(args) == 2 && args[0] == "resume" && args[1] == "normalize":
+		return normalizeResumes(db)
+	default:
+		return fmt.Errorf("unknown command %q; available commands: apikey create, migrate up|down|status, resume normalize", strings.Join(args, " "))
+	}
+}
+
+func migrate(db *gorm.DB, action string, args []string) error {
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Schema is up to date")
+		}
+		return nil
+
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "number of migrations to revert")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		reverted, err := migrator.Down(ctx, *steps)
+		for _, m := range reverted {
+			fmt.Printf("Reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("Nothing to revert")
+		}
+		return nil
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format(time.RFC3339)
+				if status.Modified {
+					state += " (modified since)"
+				}
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, state)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate action %q; use up, down or status", action)
+	}
+}
+
+func createAPIKey(db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	name := fs.String("name", "", "human readable name of the key")
+	owner := fs.String("owner", "", "owner of the key")
+	scopes := fs.String("scopes", "", "comma-separated scopes, e.g. resume:read,resume:write,session:chat")
+	expiresIn := fs.Duration("expires-in", 0, "lifetime of the key, e.g. 720h (default: never expires)")
+	role := fs.String("role", models.RoleUser, "role of the key: user or admin")
+	sellerID := fs.Uint("seller-id", 0, "seller the key writes products as")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *owner == "" || *scopes == "" {
+		return fmt.Errorf("-name, -owner and -scopes are required")
+	}
+	if *role != models.RoleUser && *role != models.RoleAdmin {
+		return fmt.Errorf("-role must be %q or %q", models.RoleUser, models.RoleAdmin)
+	}
+
+	var expiresAt *time.Time
+	if *expiresIn > 0 {
+		t := time.Now().Add(*expiresIn)
+		expiresAt = &t
+	}
+
+	var scopeList []string
+	for _, scope := range strings.Split(*scopes, ",") {
+		scope = strings.TrimSpace(scope)
+		switch {
+		case scope == "":
+			continue
+		case scope == models.RoleAdmin:
+			return fmt.Errorf("unknown scope %q; admin is a role, use -role admin", scope)
+		case !models.ValidScope(scope):
+			return fmt.Errorf("unknown scope %q; available scopes: %s", scope, strings.Join(models.Scopes, ", "))
+		}
+		scopeList = append(scopeList, scope)
+	}
+
+	key := models.APIKey{
+		Name:      *name,
+		OwnerID:   *owner,
+		Scopes:    strings.Join(scopeList, " "),
+		Role:      *role,
+		ExpiresAt: expiresAt,
+	}
+	if *sellerID != 0 {
+		id := uint(*sellerID)
+		key.SellerID = &id
+	}
+
+	plain, err := services.NewAPIKeyStore(db).Create(context.Background(), &key)
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %v", err)
+	}
+
+	fmt.Printf("Created API key %d (%s) with scopes %q\n", key.ID, key.Name, key.Scopes)
+	fmt.Println("Store it now, it will not be shown again:")
+	fmt.Println(plain)
+	return nil
+}
+
+// normalizeResumes derives the typed profile of every resume stored with an
+// older schema version. It only touches the schema columns, so neither
+// updated_at nor the version history changes.
+func normalizeResumes(db *gorm.DB) error {
+	ctx := context.Background()
+	var resumes []models.Resume
+	normalized, invalid := 0, 0
+	result := db.WithContext(ctx).Where("schema_version < ?", models.ResumeSchemaVersion).FindInBatches(&resumes, 100, func(tx *gorm.DB, batch int) error {
+		for i := range resumes {
+			resume := &resumes[i]
+			resume.ApplySchema()
+			err := db.WithContext(ctx).Model(&models.Resume{}).Where("id = ?", resume.ID).UpdateColumns(map[string]interface{}{
+				"profile":        resume.Profile,
+				"schema_version": resume.SchemaVersion,
+				"schema_status":  resume.SchemaStatus,
+				"schema_errors":  resume.SchemaErrors,
+			}).Error
+			if err != nil {
+				return fmt.Errorf("failed to normalize resume %d: %v", resume.ID, err)
+			}
+			normalized++
+			if resume.SchemaStatus == models.SchemaInvalid {
+				invalid++
+			}
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	fmt.Printf("Normalized %d resumes to schema version %d, %d of them invalid\n", normalized, models.ResumeSchemaVersion, invalid)
+	return nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"go-server/models"
+	"go-server/testdb"
+)
+
+func TestCreateAPIKeyRejectsUnknownScopes(t *testing.T) {
+	db := testdb.Open(t)
+
+	for _, scopes := range []string{"resume:read,resume:wirte", "admin"} {
+		err := createAPIKey(db, []string{"-name", "test", "-owner", "tests", "-scopes", scopes})
+		if err == nil || !strings.Contains(err.Error(), "unknown scope") {
+			t.Errorf("scopes %q: error = %v, want an unknown scope error", scopes, err)
+		}
+	}
+
+	var count int64
+	if err := db.Model(&models.APIKey{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("%d keys created, want none", count)
+	}
+}
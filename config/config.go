@@ -0,0 +1,407 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting of the server. Values are resolved from, in
+// increasing order of precedence: built-in defaults, the optional YAML file
+// named by CONFIG_FILE, the optional .env file and the process environment.
+type Config struct {
+	Database DatabaseConfig `yaml:"database"`
+	Server   ServerConfig   `yaml:"server"`
+	Swagger  SwaggerConfig  `yaml:"swagger"`
+	Parser   ParserConfig   `yaml:"parser"`
+	Chat     ChatConfig     `yaml:"chat"`
+	Session  SessionConfig  `yaml:"session"`
+	Storage  StorageConfig  `yaml:"storage"`
+	S3       S3Config       `yaml:"s3"`
+	Log      LogConfig      `yaml:"log"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+}
+
+// DatabaseConfig holds the Postgres connection settings
+type DatabaseConfig struct {
+	Host     string `yaml:"host" env:"DB_HOST"`
+	User     string `yaml:"user" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASSWORD"`
+	Name     string `yaml:"name" env:"DB_NAME"`
+	Port     int    `yaml:"port" env:"DB_PORT"`
+	SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE"`
+}
+
+// ServerConfig holds the settings of the HTTP server
+type ServerConfig struct {
+	Addr              string        `yaml:"addr" env:"SERVER_ADDR"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES"`
+	TLSCertFile       string        `yaml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile        string        `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+	// ShutdownTimeout bounds how long a graceful shutdown may take
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+// TLSEnabled reports whether both a certificate and a key are configured
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// SwaggerConfig holds the settings of the Swagger documentation
+type SwaggerConfig struct {
+	Host string `yaml:"host" env:"SWAGGER_HOST"`
+}
+
+// ParserConfig holds the settings of the resume parsing service
+type ParserConfig struct {
+	URL string `yaml:"url" env:"PARSE_API_URL"`
+	// Token is optional; without it parse requests answer 503
+	Token   string `yaml:"token" env:"PARSE_API_TOKEN"`
+	Workers int    `yaml:"workers" env:"PARSE_WORKERS"`
+	// Timeout bounds a single attempt; failed attempts are retried up to
+	// MaxRetries times
+	Timeout    time.Duration `yaml:"timeout" env:"PARSE_API_TIMEOUT"`
+	MaxRetries int           `yaml:"max_retries" env:"PARSE_API_MAX_RETRIES"`
+	// BreakerThreshold consecutive failures open the circuit breaker for
+	// BreakerOpenFor
+	BreakerThreshold int           `yaml:"breaker_threshold" env:"PARSE_API_BREAKER_THRESHOLD"`
+	BreakerOpenFor   time.Duration `yaml:"breaker_open_for" env:"PARSE_API_BREAKER_OPEN_FOR"`
+}
+
+// ChatConfig holds the settings of the chat service
+type ChatConfig struct {
+	URL string `yaml:"url" env:"CHAT_API_URL"`
+	// Timeout bounds a chat call; streamed answers only end with the
+	// client's connection
+	Timeout time.Duration `yaml:"timeout" env:"CHAT_API_TIMEOUT"`
+	// BreakerThreshold consecutive failures open the circuit breaker for
+	// BreakerOpenFor
+	BreakerThreshold int           `yaml:"breaker_threshold" env:"CHAT_API_BREAKER_THRESHOLD"`
+	BreakerOpenFor   time.Duration `yaml:"breaker_open_for" env:"CHAT_API_BREAKER_OPEN_FOR"`
+}
+
+// SessionConfig holds the chat session settings
+type SessionConfig struct {
+	// TTL is how long a session stays usable after its last activity
+	TTL time.Duration `yaml:"ttl" env:"SESSION_TTL"`
+}
+
+// StorageConfig selects where uploaded resume files are kept
+type StorageConfig struct {
+	// Backend is s3 or local
+	Backend string `yaml:"backend" env:"STORAGE_BACKEND"`
+	// Dir is the directory of the local backend
+	Dir string `yaml:"dir" env:"STORAGE_DIR"`
+	// PublicURL is the address clients reach this server at, used in the
+	// URLs signed by the local backend
+	PublicURL string `yaml:"public_url" env:"STORAGE_PUBLIC_URL"`
+	// Secret signs the local backend's URLs; a random one is used when empty
+	Secret string `yaml:"secret" env:"STORAGE_SECRET"`
+	// UploadURLExpiry and DownloadURLExpiry bound how long presigned URLs
+	// stay valid
+	UploadURLExpiry   time.Duration `yaml:"upload_url_expiry" env:"STORAGE_UPLOAD_URL_EXPIRY"`
+	DownloadURLExpiry time.Duration `yaml:"download_url_expiry" env:"STORAGE_DOWNLOAD_URL_EXPIRY"`
+	// MaxUploadBytes is the largest resume file that may be uploaded
+	MaxUploadBytes int `yaml:"max_upload_bytes" env:"STORAGE_MAX_UPLOAD_BYTES"`
+}
+
+// S3Config holds the resume upload bucket settings. Credentials come from
+// the AWS default chain (AWS_ACCESS_KEY_ID, shared config, instance role).
+// Endpoint and UsePathStyle point the client at S3-compatible servers such
+// as MinIO.
+type S3Config struct {
+	Bucket       string `yaml:"bucket" env:"AWS_S3_BUCKET"`
+	Region       string `yaml:"region" env:"AWS_REGION"`
+	Endpoint     string `yaml:"endpoint" env:"AWS_S3_ENDPOINT"`
+	UsePathStyle bool   `yaml:"use_path_style" env:"AWS_S3_USE_PATH_STYLE"`
+}
+
+// LogConfig holds the logging settings
+type LogConfig struct {
+	// Level is one of debug, info, warn or error
+	Level string `yaml:"level" env:"LOG_LEVEL"`
+}
+
+// SlogLevel returns the configured level for log/slog
+func (c LogConfig) SlogLevel() slog.Level {
+	var level slog.Level
+	level.UnmarshalText([]byte(c.Level))
+	return level
+}
+
+// TracingConfig holds the OpenTelemetry settings. The OTLP endpoint, headers
+// and protocol options are read by the exporter itself from the standard
+// OTEL_EXPORTER_OTLP_* variables.
+type TracingConfig struct {
+	// Exporter is one of otlp, console, file or none
+	Exporter    string  `yaml:"exporter" env:"OTEL_TRACES_EXPORTER"`
+	File        string  `yaml:"file" env:"OTEL_TRACES_FILE"`
+	ServiceName string  `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG"`
+}
+
+// Default returns the configuration used when nothing else is set
+func Default() Config {
+	return Config{
+		Database: DatabaseConfig{
+			Port:    5432,
+			SSLMode: "require",
+		},
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadTimeout:       30 * time.Second,
+			ReadHeaderTimeout: 10 * time.Second,
+			// Long enough for a synchronous parse; streamed chat answers clear
+			// their write deadline
+			WriteTimeout:    5 * time.Minute,
+			IdleTimeout:     2 * time.Minute,
+			MaxHeaderBytes:  1 << 20,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Swagger: SwaggerConfig{
+			Host: "localhost:8080",
+		},
+		Parser: ParserConfig{
+			URL:              "http://localhost:8000",
+			Workers:          2,
+			Timeout:          40 * time.Second,
+			MaxRetries:       2,
+			BreakerThreshold: 5,
+			BreakerOpenFor:   30 * time.Second,
+		},
+		Chat: ChatConfig{
+			URL:              "http://localhost:8000",
+			Timeout:          60 * time.Second,
+			BreakerThreshold: 5,
+			BreakerOpenFor:   30 * time.Second,
+		},
+		Session: SessionConfig{
+			TTL: 24 * time.Hour,
+		},
+		Storage: StorageConfig{
+			Backend:           "s3",
+			Dir:               "uploads",
+			PublicURL:         "http://localhost:8080",
+			UploadURLExpiry:   time.Minute,
+			DownloadURLExpiry: 5 * time.Minute,
+			MaxUploadBytes:    10 << 20,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			File:        "traces.jsonl",
+			ServiceName: "bonga",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// ValidationError lists every problem found while loading the configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load resolves and validates the configuration. A missing .env or YAML
+// file is not an error; every missing or invalid field is reported at once.
+func Load() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load .env file: %v", err)
+	}
+
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %v", err)
+		}
+		if err := yaml.Unmarshal(content, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+		}
+	}
+
+	var problems []string
+	applyEnv(reflect.ValueOf(&cfg).Elem(), &problems)
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return &cfg, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv overrides every field tagged with env whose variable is set
+func applyEnv(v reflect.Value, problems *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			applyEnv(field, problems)
+			continue
+		}
+
+		name := t.Field(i).Tag.Get("env")
+		raw, ok := os.LookupEnv(name)
+		if name == "" || !ok || raw == "" {
+			continue
+		}
+
+		switch {
+		case field.Type() == durationType:
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				*problems = append(*problems, fmt.Sprintf("%s: %q is not a duration (e.g. 30s, 5m)", name, raw))
+				continue
+			}
+			field.SetInt(int64(d))
+		case field.Kind() == reflect.Int:
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				*problems = append(*problems, fmt.Sprintf("%s: %q is not an integer", name, raw))
+				continue
+			}
+			field.SetInt(int64(n))
+		case field.Kind() == reflect.Float64:
+			f, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				*problems = append(*problems, fmt.Sprintf("%s: %q is not a number", name, raw))
+				continue
+			}
+			field.SetFloat(f)
+		case field.Kind() == reflect.Bool:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				*problems = append(*problems, fmt.Sprintf("%s: %q is not a boolean", name, raw))
+				continue
+			}
+			field.SetBool(b)
+		case field.Kind() == reflect.String:
+			field.SetString(raw)
+		}
+	}
+}
+
+func (c *Config) validate() []string {
+	var problems []string
+	require := func(value, name string) {
+		if value == "" {
+			problems = append(problems, name+" is required")
+		}
+	}
+	positive := func(d time.Duration, name string) {
+		if d <= 0 {
+			problems = append(problems, name+" must be positive")
+		}
+	}
+	httpURL := func(raw, name string) {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("%s: %q is not an http(s) URL", name, raw))
+		}
+	}
+
+	require(c.Database.Host, "DB_HOST")
+	require(c.Database.User, "DB_USER")
+	require(c.Database.Name, "DB_NAME")
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("DB_PORT: %d is not a valid port", c.Database.Port))
+	}
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		problems = append(problems, fmt.Sprintf("DB_SSLMODE: %q is not a valid sslmode", c.Database.SSLMode))
+	}
+
+	require(c.Server.Addr, "SERVER_ADDR")
+	positive(c.Server.ReadTimeout, "SERVER_READ_TIMEOUT")
+	positive(c.Server.ReadHeaderTimeout, "SERVER_READ_HEADER_TIMEOUT")
+	positive(c.Server.WriteTimeout, "SERVER_WRITE_TIMEOUT")
+	positive(c.Server.IdleTimeout, "SERVER_IDLE_TIMEOUT")
+	positive(c.Server.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+	if c.Server.MaxHeaderBytes <= 0 {
+		problems = append(problems, "SERVER_MAX_HEADER_BYTES must be positive")
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		problems = append(problems, "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	httpURL(c.Parser.URL, "PARSE_API_URL")
+	if c.Parser.Workers < 1 {
+		problems = append(problems, "PARSE_WORKERS must be at least 1")
+	}
+	positive(c.Parser.Timeout, "PARSE_API_TIMEOUT")
+	if c.Parser.MaxRetries < 0 {
+		problems = append(problems, "PARSE_API_MAX_RETRIES cannot be negative")
+	}
+	if c.Parser.BreakerThreshold < 1 {
+		problems = append(problems, "PARSE_API_BREAKER_THRESHOLD must be at least 1")
+	}
+	positive(c.Parser.BreakerOpenFor, "PARSE_API_BREAKER_OPEN_FOR")
+
+	httpURL(c.Chat.URL, "CHAT_API_URL")
+	positive(c.Chat.Timeout, "CHAT_API_TIMEOUT")
+	if c.Chat.BreakerThreshold < 1 {
+		problems = append(problems, "CHAT_API_BREAKER_THRESHOLD must be at least 1")
+	}
+	positive(c.Chat.BreakerOpenFor, "CHAT_API_BREAKER_OPEN_FOR")
+	positive(c.Session.TTL, "SESSION_TTL")
+
+	switch c.Storage.Backend {
+	case "s3":
+		if c.S3.Endpoint != "" {
+			httpURL(c.S3.Endpoint, "AWS_S3_ENDPOINT")
+		}
+	case "local":
+		require(c.Storage.Dir, "STORAGE_DIR")
+		httpURL(c.Storage.PublicURL, "STORAGE_PUBLIC_URL")
+	default:
+		problems = append(problems, fmt.Sprintf("STORAGE_BACKEND: %q is not one of s3, local", c.Storage.Backend))
+	}
+	positive(c.Storage.UploadURLExpiry, "STORAGE_UPLOAD_URL_EXPIRY")
+	positive(c.Storage.DownloadURLExpiry, "STORAGE_DOWNLOAD_URL_EXPIRY")
+	if c.Storage.UploadURLExpiry > 7*24*time.Hour || c.Storage.DownloadURLExpiry > 7*24*time.Hour {
+		problems = append(problems, "STORAGE_UPLOAD_URL_EXPIRY and STORAGE_DOWNLOAD_URL_EXPIRY cannot exceed 168h, the longest S3 presigned URLs allow")
+	}
+	if c.Storage.MaxUploadBytes <= 0 {
+		problems = append(problems, "STORAGE_MAX_UPLOAD_BYTES must be positive")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL: %q is not one of debug, info, warn, error", c.Log.Level))
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "console":
+	case "file":
+		require(c.Tracing.File, "OTEL_TRACES_FILE")
+	default:
+		problems = append(problems, fmt.Sprintf("OTEL_TRACES_EXPORTER: %q is not one of otlp, console, file, none", c.Tracing.Exporter))
+	}
+	require(c.Tracing.ServiceName, "OTEL_SERVICE_NAME")
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, "OTEL_TRACES_SAMPLER_ARG must be between 0 and 1")
+	}
+
+	return problems
+}
@@ -1,14 +0,0 @@
-package config
-
-import (
-	"os"
-)
-
-// GetSwaggerHost returns the host for Swagger documentation
-func GetSwaggerHost() string {
-	host := os.Getenv("SWAGGER_HOST")
-	if host == "" {
-		host = "localhost:8080"
-	}
-	return host
-} 
\ No newline at end of file
@@ -0,0 +1,242 @@
+// Package diff compares versions of a resume: its text line by line, in the
+// unified format of diff -u, and its metadata by JSON key path.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is how many unchanged lines surround each change in a hunk
+const contextLines = 3
+
+// The search for a shortest edit script takes memory quadratic and time
+// linear in the number of differences. Past these bounds the differing
+// region is reported as replaced as a whole, which is still a correct but
+// longer script.
+const (
+	// maxDifferences bounds the number of differences searched for
+	maxDifferences = 1000
+	// maxSearchItems bounds the combined length of the differing region
+	maxSearchItems = 20000
+)
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// edit is one step of an edit script. a and b are the positions in the
+// old and new sequence the step applies at; a delete consumes a[a], an
+// insert consumes b[b] and an equal step both.
+type edit struct {
+	kind opKind
+	a, b int
+}
+
+// editScript returns a shortest edit script turning a sequence of n items
+// into one of m items, where eq(i, j) reports whether a[i] equals b[j].
+// It uses Myers' algorithm within the bounds of maxDifferences and
+// maxSearchItems.
+func editScript(n, m int, eq func(i, j int) bool) []edit {
+	// Common prefixes and suffixes are matched without searching
+	prefix := 0
+	for prefix < n && prefix < m && eq(prefix, prefix) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < n-prefix && suffix < m-prefix && eq(n-1-suffix, m-1-suffix) {
+		suffix++
+	}
+
+	edits := make([]edit, 0, max(n, m))
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, edit{opEqual, i, i})
+	}
+	edits = append(edits, myers(prefix, n-suffix, prefix, m-suffix, eq)...)
+	for i := suffix; i > 0; i-- {
+		edits = append(edits, edit{opEqual, n - i, m - i})
+	}
+	return edits
+}
+
+// myers diffs a[a0:a1] against b[b0:b1], falling back to replace when the
+// search would exceed its bounds
+func myers(a0, a1, b0, b1 int, eq func(i, j int) bool) []edit {
+	n, m := a1-a0, b1-b0
+	if n+m > maxSearchItems {
+		return replace(a0, a1, b0, b1)
+	}
+	limit := n + m
+	// v[k+limit] is the furthest x reached on diagonal k = x - y. trace[d]
+	// keeps diagonals -d..d of v as they were before step d.
+	v := make([]int, 2*limit+2)
+	var trace [][]int
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		if d > maxDifferences {
+			return replace(a0, a1, b0, b1)
+		}
+		trace = append(trace, append([]int(nil), v[limit-d:limit+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[limit+k-1] < v[limit+k+1]) {
+				x = v[limit+k+1]
+			} else {
+				x = v[limit+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && eq(a0+x, b0+y) {
+				x++
+				y++
+			}
+			v[limit+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// Walk back from the end to recover the path
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = at(prevK)
+		}
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{opEqual, a0 + x, b0 + y})
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{opInsert, a0 + prevX, b0 + prevY})
+			} else {
+				edits = append(edits, edit{opDelete, a0 + prevX, b0 + prevY})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// replace turns a[a0:a1] into b[b0:b1] by deleting and then inserting
+// every item
+func replace(a0, a1, b0, b1 int) []edit {
+	edits := make([]edit, 0, a1-a0+b1-b0)
+	for i := a0; i < a1; i++ {
+		edits = append(edits, edit{opDelete, i, b0})
+	}
+	for j := b0; j < b1; j++ {
+		edits = append(edits, edit{opInsert, a1, j})
+	}
+	return edits
+}
+
+// Lines returns the unified diff of two texts, labelled fromName and
+// toName, or an empty string when they are equal
+func Lines(fromName, toName, a, b string) string {
+	aLines, bLines := splitLines(a), splitLines(b)
+	edits := editScript(len(aLines), len(bLines), func(i, j int) bool { return aLines[i] == bLines[j] })
+
+	var out strings.Builder
+	for _, h := range hunks(edits) {
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		first := edits[h[0]]
+		aCount, bCount := 0, 0
+		for _, e := range edits[h[0]:h[1]] {
+			if e.kind != opInsert {
+				aCount++
+			}
+			if e.kind != opDelete {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(first.a, aCount), hunkRange(first.b, bCount))
+		for _, e := range edits[h[0]:h[1]] {
+			switch e.kind {
+			case opEqual:
+				out.WriteString(" " + aLines[e.a] + "\n")
+			case opDelete:
+				out.WriteString("-" + aLines[e.a] + "\n")
+			case opInsert:
+				out.WriteString("+" + bLines[e.b] + "\n")
+			}
+		}
+	}
+	return out.String()
+}
+
+// hunks groups the changes of an edit script into [start, end) ranges of
+// edits, each with up to contextLines unchanged lines around it. Changes
+// close enough for their context to touch share a hunk.
+func hunks(edits []edit) [][2]int {
+	var ranges [][2]int
+	for i := 0; i < len(edits); {
+		if edits[i].kind == opEqual {
+			i++
+			continue
+		}
+		start, end := max(0, i-contextLines), i
+		for {
+			for end < len(edits) && edits[end].kind != opEqual {
+				end++
+			}
+			next := end
+			for next < len(edits) && edits[next].kind == opEqual {
+				next++
+			}
+			if next < len(edits) && next-end <= 2*contextLines {
+				end = next
+				continue
+			}
+			end = min(end+contextLines, len(edits))
+			break
+		}
+		ranges = append(ranges, [2]int{start, end})
+		i = end
+	}
+	return ranges
+}
+
+// hunkRange formats the line range of one side of a hunk. As in diff -u,
+// an empty range starts at the line before it.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits text into lines, ignoring one trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
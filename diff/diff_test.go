@@ -0,0 +1,201 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "a\nb\n", "a\nb", ""},
+		{"both empty", "", "", ""},
+		{"from empty", "", "a\nb\n", "--- v1\n+++ v2\n@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"to empty", "a\n", "", "--- v1\n+++ v2\n@@ -1 +0,0 @@\n-a\n"},
+		{
+			"change in the middle",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			"1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			"--- v1\n+++ v2\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			"distant changes make separate hunks",
+			"a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			"A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			"--- v1\n+++ v2\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n",
+		},
+		{
+			"close changes share a hunk",
+			"a\n1\n2\n3\nb\n",
+			"A\n1\n2\n3\nB\n",
+			"--- v1\n+++ v2\n@@ -1,5 +1,5 @@\n-a\n+A\n 1\n 2\n 3\n-b\n+B\n",
+		},
+		{
+			"insertion",
+			"Experience\nAcme\nSkills\n",
+			"Experience\nGlobex\nAcme\nSkills\n",
+			"--- v1\n+++ v2\n@@ -1,3 +1,4 @@\n Experience\n+Globex\n Acme\n Skills\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Lines("v1", "v2", tc.a, tc.b); got != tc.want {
+				t.Errorf("Lines =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+// apply rebuilds the new text from an edit script, checking that the
+// script is consistent with both texts
+func apply(t *testing.T, a, b []string, edits []edit) []string {
+	t.Helper()
+	var out []string
+	i, j := 0, 0
+	for _, e := range edits {
+		if e.a != i || e.b != j {
+			t.Fatalf("edit %+v at position (%d, %d)", e, i, j)
+		}
+		switch e.kind {
+		case opEqual:
+			if a[i] != b[j] {
+				t.Fatalf("equal edit of %q and %q", a[i], b[j])
+			}
+			out = append(out, a[i])
+			i, j = i+1, j+1
+		case opDelete:
+			i++
+		case opInsert:
+			out = append(out, b[j])
+			j++
+		}
+	}
+	if i != len(a) || j != len(b) {
+		t.Fatalf("script ends at (%d, %d), want (%d, %d)", i, j, len(a), len(b))
+	}
+	return out
+}
+
+func TestEditScript(t *testing.T) {
+	cases := []struct {
+		a, b    string
+		changes int
+	}{
+		{"abcabba", "cbabac", 5},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"abc", "abc", 0},
+		{"xaby", "xbay", 2},
+		{"kitten", "sitting", 5},
+	}
+	for _, tc := range cases {
+		a, b := strings.Split(tc.a, ""), strings.Split(tc.b, "")
+		edits := editScript(len(a), len(b), func(i, j int) bool { return a[i] == b[j] })
+		if got := apply(t, a, b, edits); strings.Join(got, "") != tc.b {
+			t.Errorf("%q -> %q: script produces %q", tc.a, tc.b, strings.Join(got, ""))
+		}
+		changes := 0
+		for _, e := range edits {
+			if e.kind != opEqual {
+				changes++
+			}
+		}
+		if changes != tc.changes {
+			t.Errorf("%q -> %q: %d changes, want %d", tc.a, tc.b, changes, tc.changes)
+		}
+	}
+}
+
+func TestEditScriptBounds(t *testing.T) {
+	// Unrelated texts beyond maxDifferences, and a long differing region,
+	// are replaced as a whole after the common prefix and suffix
+	numbered := func(prefix string, n int) []string {
+		lines := make([]string, n)
+		for i := range lines {
+			lines[i] = fmt.Sprintf("%s%d", prefix, i)
+		}
+		return lines
+	}
+	cases := []struct {
+		name string
+		a, b []string
+	}{
+		{"many differences", numbered("a", maxDifferences), numbered("b", maxDifferences)},
+		{"long region", append([]string{"moved"}, numbered("a", maxSearchItems/2)...), append(numbered("a", maxSearchItems/2), "moved")},
+	}
+	for _, tc := range cases {
+		a := append(append([]string{"head"}, tc.a...), "tail")
+		b := append(append([]string{"head"}, tc.b...), "tail")
+		edits := editScript(len(a), len(b), func(i, j int) bool { return a[i] == b[j] })
+		if got := apply(t, a, b, edits); strings.Join(got, "\n") != strings.Join(b, "\n") {
+			t.Errorf("%s: script does not produce the new text", tc.name)
+		}
+		changes := 0
+		for _, e := range edits {
+			if e.kind != opEqual {
+				changes++
+			}
+		}
+		if want := len(tc.a) + len(tc.b); changes != want {
+			t.Errorf("%s: %d changes, want the whole region replaced (%d)", tc.name, changes, want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	decode := func(s string) map[string]interface{} {
+		var v map[string]interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	a := decode(`{
+		"name": "Alice",
+		"email": "alice@example.com",
+		"skills": ["go", "sql", "docker"],
+		"experience": [
+			{"title": "Engineer", "company": "Acme"},
+			{"title": "Intern", "company": "Initech"}
+		]
+	}`)
+	b := decode(`{
+		"name": "Alice",
+		"phone": "555-0100",
+		"skills": ["go", "rust", "sql"],
+		"experience": [
+			{"title": "Senior Engineer", "company": "Globex"},
+			{"title": "Engineer", "company": "Acme"},
+			{"title": "Intern", "company": "Initech", "years": 1}
+		]
+	}`)
+
+	want := []Change{
+		{Path: "email", Op: Removed, From: "alice@example.com"},
+		{Path: "experience[0]", Op: Added, To: map[string]interface{}{"title": "Senior Engineer", "company": "Globex"}},
+		{Path: "experience[2].years", Op: Added, To: 1.0},
+		{Path: "phone", Op: Added, To: "555-0100"},
+		{Path: "skills[1]", Op: Added, To: "rust"},
+		{Path: "skills[2]", Op: Removed, From: "docker"},
+	}
+	if got := JSON(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("JSON =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := JSON(a, a); len(got) != 0 {
+		t.Errorf("JSON of equal documents = %+v", got)
+	}
+	if got := JSON(nil, decode(`{"x": 1}`)); !reflect.DeepEqual(got, []Change{{Path: "x", Op: Added, To: 1.0}}) {
+		t.Errorf("JSON from nil = %+v", got)
+	}
+	if got := JSON(decode(`{"x": 1}`), decode(`{"x": "1"}`)); !reflect.DeepEqual(got, []Change{{Path: "x", Op: Changed, From: 1.0, To: "1"}}) {
+		t.Errorf("JSON of a type change = %+v", got)
+	}
+}
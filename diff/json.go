@@ -0,0 +1,118 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Change operations
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is one difference between two JSON documents. Path names the
+// value with dotted object keys and [i] array indices; indices count in
+// the old document for removals and in the new one otherwise.
+type Change struct {
+	Path string      `json:"path" example:"skills[2]"`
+	Op   string      `json:"op" example:"added"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// JSON compares two decoded JSON objects. Arrays are aligned like lines of
+// text, so inserting an element reports one addition rather than a change
+// of every element after it; objects replacing each other at the same
+// position are compared key by key.
+func JSON(a, b map[string]interface{}) []Change {
+	changes := []Change{}
+	compareObjects("", a, b, &changes)
+	return changes
+}
+
+func compare(path string, a, b interface{}, changes *[]Change) {
+	aObject, aIsObject := a.(map[string]interface{})
+	bObject, bIsObject := b.(map[string]interface{})
+	if aIsObject && bIsObject {
+		compareObjects(path, aObject, bObject, changes)
+		return
+	}
+	aArray, aIsArray := a.([]interface{})
+	bArray, bIsArray := b.([]interface{})
+	if aIsArray && bIsArray {
+		compareArrays(path, aArray, bArray, changes)
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: path, Op: Changed, From: a, To: b})
+	}
+}
+
+func compareObjects(path string, a, b map[string]interface{}, changes *[]Change) {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+		aValue, inA := a[key]
+		bValue, inB := b[key]
+		switch {
+		case !inB:
+			*changes = append(*changes, Change{Path: keyPath, Op: Removed, From: aValue})
+		case !inA:
+			*changes = append(*changes, Change{Path: keyPath, Op: Added, To: bValue})
+		default:
+			compare(keyPath, aValue, bValue, changes)
+		}
+	}
+}
+
+func compareArrays(path string, a, b []interface{}, changes *[]Change) {
+	index := func(i int) string { return fmt.Sprintf("%s[%d]", path, i) }
+	edits := editScript(len(a), len(b), func(i, j int) bool { return reflect.DeepEqual(a[i], b[j]) })
+
+	for i := 0; i < len(edits); {
+		if edits[i].kind == opEqual {
+			i++
+			continue
+		}
+		// Pair the removals of a run of changes with its insertions
+		var removed, inserted []edit
+		for ; i < len(edits) && edits[i].kind != opEqual; i++ {
+			if edits[i].kind == opDelete {
+				removed = append(removed, edits[i])
+			} else {
+				inserted = append(inserted, edits[i])
+			}
+		}
+		for len(removed) > 0 && len(inserted) > 0 {
+			_, aIsObject := a[removed[0].a].(map[string]interface{})
+			_, bIsObject := b[inserted[0].b].(map[string]interface{})
+			if !aIsObject || !bIsObject {
+				break
+			}
+			compare(index(inserted[0].b), a[removed[0].a], b[inserted[0].b], changes)
+			removed, inserted = removed[1:], inserted[1:]
+		}
+		for _, e := range removed {
+			*changes = append(*changes, Change{Path: index(e.a), Op: Removed, From: a[e.a]})
+		}
+		for _, e := range inserted {
+			*changes = append(*changes, Change{Path: index(e.b), Op: Added, To: b[e.b]})
+		}
+	}
+}
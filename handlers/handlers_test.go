@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-server/middleware"
+	"go-server/models"
+	"go-server/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fakeKeys authenticates the plain keys it maps to API keys
+type fakeKeys map[string]*models.APIKey
+
+func (k fakeKeys) Authenticate(ctx context.Context, plain string) (*models.APIKey, error) {
+	if key, ok := k[plain]; ok {
+		return key, nil
+	}
+	return nil, services.ErrInvalidAPIKey
+}
+
+// newTestEngine returns an engine rendering errors the way the server does
+func newTestEngine() *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.Recovery(), middleware.Problems())
+	r.NoRoute(middleware.NoRoute)
+	return r
+}
+
+// serve sends a request with an optional JSON body and API key to r
+func serve(t *testing.T, r http.Handler, method, target, apiKey string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encode request body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// decode unmarshals the response body into v, failing on a status other
+// than want
+func decode(t *testing.T, w *httptest.ResponseRecorder, want int, v interface{}) {
+	t.Helper()
+	if w.Code != want {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, want, w.Body)
+	}
+	if v != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("decode response %s: %v", w.Body, err)
+		}
+	}
+}
+
+// expectProblem checks that the response is a problem with status and code
+func expectProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code string) middleware.Problem {
+	t.Helper()
+	var problem middleware.Problem
+	decode(t, w, status, &problem)
+	if ct := w.Header().Get("Content-Type"); ct != middleware.ProblemContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, middleware.ProblemContentType)
+	}
+	if problem.Code != code {
+		t.Errorf("problem code = %q, want %q (%+v)", problem.Code, code, problem)
+	}
+	return problem
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout bounds every individual readiness check
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck probes a single dependency. Details are included in the
+// readiness report whether or not the check passes; errors are only logged,
+// the report names the failed check and whether it timed out.
+type HealthCheck struct {
+	Name string
+	// Critical checks take the pod out of rotation when they fail; the
+	// others only mark it as degraded
+	Critical bool
+	Check    func(ctx context.Context) (details interface{}, err error)
+}
+
+// Readiness check statuses
+const (
+	CheckOK          = "ok"
+	CheckUnavailable = "unavailable"
+	CheckTimeout     = "timeout"
+)
+
+// CheckResult is the outcome of a single readiness check
+type CheckResult struct {
+	Status    string      `json:"status" example:"ok" enums:"ok,unavailable,timeout"`
+	Critical  bool        `json:"critical" example:"true"`
+	LatencyMs int64       `json:"latency_ms" example:"3"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// ReadinessResponse reports the state of every dependency
+type ReadinessResponse struct {
+	Status string                 `json:"status" example:"ok"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type HealthHandler struct {
+	checks []HealthCheck
+}
+
+func NewHealthHandler(checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Livez godoc
+// @Summary Liveness probe
+// @Description Reports that the process is running and able to serve requests. It does not check any dependency.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Checks every dependency concurrently. Responds 503 when a critical dependency (database, storage) is down and reports "degraded" when only an upstream service is.
+// @Tags health
+// @Produce json
+// @Success 200 {object} ReadinessResponse
+// @Failure 503 {object} ReadinessResponse
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	results := make(map[string]CheckResult, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+			defer cancel()
+
+			started := time.Now()
+			details, err := check.Check(ctx)
+			result := CheckResult{
+				Status:    CheckOK,
+				Critical:  check.Critical,
+				LatencyMs: time.Since(started).Milliseconds(),
+				Details:   details,
+			}
+			if err != nil {
+				result.Status = CheckUnavailable
+				if errors.Is(err, context.DeadlineExceeded) || ctx.Err() == context.DeadlineExceeded {
+					result.Status = CheckTimeout
+				}
+				slog.WarnContext(ctx, "readiness check failed", "check", check.Name, "status", result.Status, "error", err)
+			}
+
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	response := ReadinessResponse{Status: "ok", Checks: results}
+	for _, result := range results {
+		if result.Status == CheckOK {
+			continue
+		}
+		if result.Critical {
+			response.Status = "unavailable"
+			break
+		}
+		response.Status = "degraded"
+	}
+
+	status := http.StatusOK
+	if response.Status == "unavailable" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReadyzHidesCheckErrors(t *testing.T) {
+	h := NewHealthHandler(
+		HealthCheck{Name: "database", Critical: true, Check: func(ctx context.Context) (interface{}, error) {
+			return nil, errors.New("dial tcp 10.0.0.5:5432: password authentication failed for user bonga")
+		}},
+		HealthCheck{Name: "chat", Check: func(ctx context.Context) (interface{}, error) {
+			return nil, fmt.Errorf("GET http://chat.internal/health: %w", context.DeadlineExceeded)
+		}},
+		HealthCheck{Name: "storage", Critical: true, Check: func(ctx context.Context) (interface{}, error) {
+			return nil, nil
+		}},
+	)
+	r := newTestEngine()
+	r.GET("/readyz", h.Readyz)
+
+	w := serve(t, r, http.MethodGet, "/readyz", "", nil)
+	if strings.Contains(w.Body.String(), "10.0.0.5") || strings.Contains(w.Body.String(), "password") {
+		t.Errorf("readiness report leaks the check error: %s", w.Body)
+	}
+
+	var report ReadinessResponse
+	decode(t, w, http.StatusServiceUnavailable, &report)
+	want := map[string]string{"database": CheckUnavailable, "chat": CheckTimeout, "storage": CheckOK}
+	for name, status := range want {
+		if report.Checks[name].Status != status {
+			t.Errorf("%s status = %q, want %q", name, report.Checks[name].Status, status)
+		}
+	}
+	if report.Status != "unavailable" {
+		t.Errorf("status = %q, want unavailable", report.Status)
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-server/middleware"
+	"go-server/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// PageLinks holds navigation links for a paginated response
+type PageLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// PageMeta describes the position of a page within a result set
+type PageMeta struct {
+	Page  int       `json:"page" example:"1"`
+	Limit int       `json:"limit" example:"20"`
+	Total int64     `json:"total" example:"42"`
+	Links PageLinks `json:"links"`
+}
+
+// pagination is the parsed page/limit pair of a list request
+type pagination struct {
+	Page  int
+	Limit int
+}
+
+// parsePagination reads the page and limit query parameters, applying
+// defaults and clamping the limit to maxPageLimit
+func parsePagination(c *gin.Context) (pagination, error) {
+	p := pagination{Page: 1, Limit: defaultPageLimit}
+
+	if raw := c.Query("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			return p, middleware.InvalidField("page", "must be a positive integer")
+		}
+		p.Page = page
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return p, middleware.InvalidField("limit", "must be a positive integer")
+		}
+		if limit > maxPageLimit {
+			limit = maxPageLimit
+		}
+		p.Limit = limit
+	}
+
+	return p, nil
+}
+
+func (p pagination) offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// options turns the page into repository list options sorted by sort
+func (p pagination) options(sort []repository.SortField) repository.ListOptions {
+	return repository.ListOptions{Offset: p.offset(), Limit: p.Limit, Sort: sort}
+}
+
+// meta builds the page metadata, including self/next/prev links that keep
+// every other query parameter of the original request
+func (p pagination) meta(c *gin.Context, total int64) PageMeta {
+	meta := PageMeta{
+		Page:  p.Page,
+		Limit: p.Limit,
+		Total: total,
+		Links: PageLinks{Self: pageLink(c, p.Page, p.Limit)},
+	}
+	if int64(p.offset()+p.Limit) < total {
+		meta.Links.Next = pageLink(c, p.Page+1, p.Limit)
+	}
+	if p.Page > 1 {
+		meta.Links.Prev = pageLink(c, p.Page-1, p.Limit)
+	}
+	return meta
+}
+
+func pageLink(c *gin.Context, page, limit int) string {
+	query := c.Request.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	query.Set("limit", strconv.Itoa(limit))
+	u := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// parseSort turns a sort parameter such as "-created_at,id" into sort
+// fields. Only fields present in allowed are accepted; a leading "-" sorts
+// descending.
+func parseSort(raw string, allowed map[string]string, fallback []repository.SortField) ([]repository.SortField, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+
+	var fields []repository.SortField
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+		column, ok := allowed[field]
+		if !ok {
+			return nil, middleware.InvalidField("sort", fmt.Sprintf("cannot sort by %q", field))
+		}
+		fields = append(fields, repository.SortField{Column: column, Desc: desc})
+	}
+	return fields, nil
+}
+
+// parseTimeQuery reads an optional RFC 3339 timestamp query parameter
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, middleware.InvalidField(name, "must be an RFC 3339 timestamp")
+	}
+	return &t, nil
+}
+
+// parseTimeRange reads the optional <prefix>_after and <prefix>_before
+// query parameters
+func parseTimeRange(c *gin.Context, prefix string) (repository.TimeRange, error) {
+	after, err := parseTimeQuery(c, prefix+"_after")
+	if err != nil {
+		return repository.TimeRange{}, err
+	}
+	before, err := parseTimeQuery(c, prefix+"_before")
+	if err != nil {
+		return repository.TimeRange{}, err
+	}
+	return repository.TimeRange{After: after, Before: before}, nil
+}
@@ -2,32 +2,92 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 
-	"github.com/gin-gonic/gin"
+	"go-server/middleware"
 	"go-server/models"
-	"gorm.io/gorm"
+	"go-server/repository"
+
+	"github.com/gin-gonic/gin"
 )
 
+type ProductHandler struct {
+	Products repository.ProductRepository
+}
 
+// ProductListResponse is a page of products
+type ProductListResponse struct {
+	Items []models.Product `json:"items"`
+	PageMeta
+}
 
-type ProductHandler struct {
-	DB *gorm.DB
+var productSortFields = map[string]string{
+	"id":         "id",
+	"title":      "title",
+	"seller_id":  "seller_id",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
 }
 
 // @Summary Get all products
-// @Description Get a list of all products
+// @Description Get a paginated list of products, optionally filtered and sorted
 // @Tags products
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.Product
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param seller_id query int false "Only products of this seller"
+// @Param title query string false "Case-insensitive title substring"
+// @Param created_after query string false "Created at or after (RFC 3339)"
+// @Param created_before query string false "Created before (RFC 3339)"
+// @Param updated_after query string false "Updated at or after (RFC 3339)"
+// @Param updated_before query string false "Updated before (RFC 3339)"
+// @Param sort query string false "Comma-separated sort fields (id, title, seller_id, created_at, updated_at); prefix with - for descending" default(id)
+// @Success 200 {object} ProductListResponse
+// @Failure 400 {object} middleware.Problem
 // @Router /api/v1/products [get]
 func (h *ProductHandler) GetProducts(c *gin.Context) {
-	var products []models.Product
-	if err := h.DB.Find(&products).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	page, err := parsePagination(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	sort, err := parseSort(c.Query("sort"), productSortFields, []repository.SortField{{Column: "id"}})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filter := repository.ProductFilter{Title: c.Query("title")}
+	if filter.Created, err = parseTimeRange(c, "created"); err != nil {
+		c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, products)
+	if filter.Updated, err = parseTimeRange(c, "updated"); err != nil {
+		c.Error(err)
+		return
+	}
+	if raw := c.Query("seller_id"); raw != "" {
+		sellerID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.Error(middleware.InvalidField("seller_id", "must be a positive integer"))
+			return
+		}
+		id := uint(sellerID)
+		filter.SellerID = &id
+	}
+
+	products, total, err := h.Products.List(c.Request.Context(), filter, page.options(sort))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ProductListResponse{
+		Items:    products,
+		PageMeta: page.meta(c, total),
+	})
 }
 
 // @Summary Get a product
@@ -37,84 +97,173 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Product ID"
 // @Success 200 {object} models.Product
+// @Failure 400 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
 // @Router /api/v1/products/{id} [get]
 func (h *ProductHandler) GetProduct(c *gin.Context) {
-	var product models.Product
-	if err := h.DB.First(&product, c.Param("id")).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	product, err := h.Products.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(lookupError(err, "Product not found"))
 		return
 	}
 	c.JSON(http.StatusOK, product)
 }
 
 // @Summary Create a product
-// @Description Create a new product happily
+// @Description Create a new product happily. The seller is taken from the API key; admins may set seller_id explicitly.
 // @Tags products
 // @Accept json
 // @Produce json
-// @Param product body models.Product true "Product object"
+// @Param Authorization header string true "API Key"
+// @Param product body models.ProductRequest true "Product object"
 // @Success 201 {object} models.Product
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
 // @Router /api/v1/products [post]
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
-	var product models.Product
-	if err := c.ShouldBindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var request models.ProductRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(middleware.BindingProblem(err))
+		return
+	}
+
+	sellerID, ok := resolveSellerID(c, request.SellerID)
+	if !ok {
 		return
 	}
 
-	if err := h.DB.Create(&product).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	product := models.Product{
+		SellerID:    sellerID,
+		Title:       request.Title,
+		Description: request.Description,
+	}
+	if err := h.Products.Create(c.Request.Context(), &product); err != nil {
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusCreated, product)
 }
 
 // @Summary Update a product
-// @Description Update a product by ID
+// @Description Update a product by ID. Sellers can only update their own products; admins can update any product and move it to another seller.
 // @Tags products
 // @Accept json
 // @Produce json
+// @Param Authorization header string true "API Key"
 // @Param id path int true "Product ID"
-// @Param product body models.Product true "Product object"
+// @Param product body models.ProductRequest true "Product object"
 // @Success 200 {object} models.Product
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
 // @Router /api/v1/products/{id} [put]
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
-	var product models.Product
-	if err := h.DB.First(&product, c.Param("id")).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	if err := c.ShouldBindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	product, err := h.Products.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(lookupError(err, "Product not found"))
 		return
 	}
 
-	if err := h.DB.Save(&product).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !authorizeProductOwner(c, product) {
+		return
+	}
+
+	var request models.ProductRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(middleware.BindingProblem(err))
+		return
+	}
+
+	product.Title = request.Title
+	product.Description = request.Description
+	if request.SellerID != 0 && middleware.CurrentAPIKey(c).IsAdmin() {
+		product.SellerID = request.SellerID
+	}
+
+	if err := h.Products.Update(c.Request.Context(), product); err != nil {
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, product)
 }
 
 // @Summary Delete a product
-// @Description Delete a product by ID
+// @Description Delete a product by ID. Sellers can only delete their own products; admins can delete any product.
 // @Tags products
 // @Accept json
 // @Produce json
+// @Param Authorization header string true "API Key"
 // @Param id path int true "Product ID"
 // @Success 204 "No Content"
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
 // @Router /api/v1/products/{id} [delete]
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
-	var product models.Product
-	if err := h.DB.First(&product, c.Param("id")).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	product, err := h.Products.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(lookupError(err, "Product not found"))
 		return
 	}
 
-	if err := h.DB.Delete(&product).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !authorizeProductOwner(c, product) {
+		return
+	}
+
+	if err := h.Products.Delete(c.Request.Context(), product.ID); err != nil {
+		c.Error(err)
 		return
 	}
 	c.Status(http.StatusNoContent)
-} 
\ No newline at end of file
+}
+
+// resolveSellerID returns the seller a new product belongs to: the seller of
+// the API key, or the requested seller for admins
+func resolveSellerID(c *gin.Context, requested uint) (uint, bool) {
+	key := middleware.CurrentAPIKey(c)
+	if key.IsAdmin() {
+		if requested == 0 {
+			c.Error(middleware.InvalidField("seller_id", "is required for admin keys"))
+			return 0, false
+		}
+		return requested, true
+	}
+
+	if key.SellerID == nil {
+		c.Error(middleware.NewProblem(http.StatusForbidden, middleware.CodeForbidden, "API key is not linked to a seller"))
+		return 0, false
+	}
+	return *key.SellerID, true
+}
+
+// authorizeProductOwner rejects the request with 403 unless the API key
+// belongs to the product's seller or is an admin key
+func authorizeProductOwner(c *gin.Context, product *models.Product) bool {
+	key := middleware.CurrentAPIKey(c)
+	if key.IsAdmin() || (key.SellerID != nil && *key.SellerID == product.SellerID) {
+		return true
+	}
+	c.Error(middleware.NewProblem(http.StatusForbidden, middleware.CodeForbidden, "Product belongs to another seller"))
+	return false
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go-server/middleware"
+	"go-server/models"
+	"go-server/repository"
+)
+
+func newProductRouter(t *testing.T) (http.Handler, *repository.MemoryProducts) {
+	t.Helper()
+
+	products := repository.NewMemoryProducts()
+	h := &ProductHandler{Products: products}
+	keys := fakeKeys{
+		"seller1": {Scopes: models.ScopeProductWrite, Role: models.RoleUser, SellerID: ptr(uint(1))},
+		"seller2": {Scopes: models.ScopeProductWrite, Role: models.RoleUser, SellerID: ptr(uint(2))},
+		"admin":   {Scopes: models.ScopeProductWrite, Role: models.RoleAdmin},
+		"reader":  {Scopes: models.ScopeResumeRead, Role: models.RoleUser},
+	}
+
+	r := newTestEngine()
+	r.GET("/products", h.GetProducts)
+	r.GET("/products/:id", h.GetProduct)
+	write := r.Group("/products", middleware.APIKeyAuth(keys, models.ScopeProductWrite))
+	write.POST("", h.CreateProduct)
+	write.PUT("/:id", h.UpdateProduct)
+	write.DELETE("/:id", h.DeleteProduct)
+	return r, products
+}
+
+func TestCreateProductUsesKeySeller(t *testing.T) {
+	r, _ := newProductRouter(t)
+
+	var product models.Product
+	w := serve(t, r, http.MethodPost, "/products", "seller1", models.ProductRequest{SellerID: 2, Title: "Mug"})
+	decode(t, w, http.StatusCreated, &product)
+	if product.SellerID != 1 {
+		t.Errorf("seller_id = %d, want the key's seller 1", product.SellerID)
+	}
+
+	w = serve(t, r, http.MethodPost, "/products", "admin", models.ProductRequest{SellerID: 2, Title: "Lamp"})
+	decode(t, w, http.StatusCreated, &product)
+	if product.SellerID != 2 {
+		t.Errorf("admin seller_id = %d, want the requested seller 2", product.SellerID)
+	}
+
+	w = serve(t, r, http.MethodPost, "/products", "admin", models.ProductRequest{Title: "Lamp"})
+	expectProblem(t, w, http.StatusBadRequest, middleware.CodeValidationFailed)
+}
+
+func TestCreateProductRejectsBadRequests(t *testing.T) {
+	r, _ := newProductRouter(t)
+
+	cases := []struct {
+		name   string
+		apiKey string
+		body   interface{}
+		status int
+		code   string
+	}{
+		{"no key", "", models.ProductRequest{Title: "Mug"}, http.StatusUnauthorized, middleware.CodeUnauthorized},
+		{"unknown key", "nope", models.ProductRequest{Title: "Mug"}, http.StatusUnauthorized, middleware.CodeUnauthorized},
+		{"missing scope", "reader", models.ProductRequest{Title: "Mug"}, http.StatusForbidden, middleware.CodeForbidden},
+		{"missing title", "seller1", models.ProductRequest{}, http.StatusBadRequest, middleware.CodeValidationFailed},
+		{"wrong type", "seller1", map[string]interface{}{"title": 5}, http.StatusBadRequest, middleware.CodeValidationFailed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := serve(t, r, http.MethodPost, "/products", tc.apiKey, tc.body)
+			expectProblem(t, w, tc.status, tc.code)
+		})
+	}
+}
+
+func TestUpdateAndDeleteProductRequireOwner(t *testing.T) {
+	r, products := newProductRouter(t)
+	product := models.Product{SellerID: 1, Title: "Mug"}
+	if err := products.Create(context.Background(), &product); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(t, r, http.MethodPut, "/products/1", "seller2", models.ProductRequest{Title: "Stolen"})
+	expectProblem(t, w, http.StatusForbidden, middleware.CodeForbidden)
+	w = serve(t, r, http.MethodDelete, "/products/1", "seller2", nil)
+	expectProblem(t, w, http.StatusForbidden, middleware.CodeForbidden)
+
+	var updated models.Product
+	w = serve(t, r, http.MethodPut, "/products/1", "seller1", models.ProductRequest{Title: "Big Mug"})
+	decode(t, w, http.StatusOK, &updated)
+	if updated.Title != "Big Mug" || updated.SellerID != 1 {
+		t.Errorf("updated product = %+v", updated)
+	}
+
+	w = serve(t, r, http.MethodDelete, "/products/1", "admin", nil)
+	decode(t, w, http.StatusNoContent, nil)
+	w = serve(t, r, http.MethodGet, "/products/1", "", nil)
+	expectProblem(t, w, http.StatusNotFound, middleware.CodeNotFound)
+	w = serve(t, r, http.MethodDelete, "/products/1", "admin", nil)
+	expectProblem(t, w, http.StatusNotFound, middleware.CodeNotFound)
+}
+
+func TestGetProducts(t *testing.T) {
+	r, products := newProductRouter(t)
+	for _, p := range []models.Product{
+		{SellerID: 1, Title: "Blue Mug"},
+		{SellerID: 1, Title: "Shirt"},
+		{SellerID: 2, Title: "Red mug"},
+	} {
+		p := p
+		if err := products.Create(context.Background(), &p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var page ProductListResponse
+	w := serve(t, r, http.MethodGet, "/products?title=mug&sort=-title&limit=1", "", nil)
+	decode(t, w, http.StatusOK, &page)
+	if page.Total != 2 || len(page.Items) != 1 || page.Items[0].Title != "Red mug" {
+		t.Errorf("page = %+v, want Red mug of 2 matches", page)
+	}
+	if page.Links.Next == "" || page.Links.Prev != "" {
+		t.Errorf("links = %+v, want only a next link", page.Links)
+	}
+
+	cases := []struct {
+		query string
+		field string
+	}{
+		{"page=0", "page"},
+		{"limit=x", "limit"},
+		{"sort=description", "sort"},
+		{"seller_id=-1", "seller_id"},
+		{"created_after=yesterday", "created_after"},
+	}
+	for _, tc := range cases {
+		w := serve(t, r, http.MethodGet, "/products?"+tc.query, "", nil)
+		problem := expectProblem(t, w, http.StatusBadRequest, middleware.CodeValidationFailed)
+		if len(problem.Errors) != 1 || problem.Errors[0].Field != tc.field {
+			t.Errorf("%s: errors = %+v, want one for %s", tc.query, problem.Errors, tc.field)
+		}
+	}
+}
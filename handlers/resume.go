@@ -1,121 +1,230 @@
 package handlers
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"net/url"
-	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"go-server/metrics"
+	"go-server/middleware"
 	"go-server/models"
+	"go-server/repository"
 	"go-server/services"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type ResumeHandler struct {
-	db        *gorm.DB
-	s3Service *services.S3Service
+	resumes  repository.ResumeRepository
+	uploads  repository.UploadRepository
+	storage  services.Storage
+	settings UploadSettings
+	parser   services.ResumeParser
+	jobs     *services.ParseQueue
 }
 
-func NewResumeHandler(db *gorm.DB) *ResumeHandler {
-	s3Service, err := services.NewS3Service()
-	if err != nil {
-		// Log the error but continue without S3 service
-		// You might want to handle this differently based on your requirements
-		return &ResumeHandler{db: db}
-	}
+// NewResumeHandler creates the resume handler. storage may be nil, in which
+// case upload URLs are unavailable.
+func NewResumeHandler(resumes repository.ResumeRepository, uploads repository.UploadRepository, storage services.Storage, settings UploadSettings, parser services.ResumeParser, jobs *services.ParseQueue) *ResumeHandler {
 	return &ResumeHandler{
-		db:        db,
-		s3Service: s3Service,
+		resumes:  resumes,
+		uploads:  uploads,
+		storage:  storage,
+		settings: settings,
+		parser:   parser,
+		jobs:     jobs,
 	}
 }
 
 // CreateResume godoc
 // @Summary Create a new resume
-// @Description Create a new resume by parsing a file through external service
+// @Description Create a new resume by parsing an uploaded file through external service. The file must be a completed upload of the API key's owner, named by uploadId or by its storage key in fileName; it becomes the resume's source file. With async=true the file is queued for background parsing and a job is returned immediately; poll /api/v1/resume/jobs/{id} for the result.
 // @Tags resume
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "API Key"
 // @Param request body models.ParseResumeRequest true "Parse Resume Request"
+// @Param async query bool false "Queue the file for background parsing"
 // @Success 201 {object} map[string]interface{}
+// @Success 202 {object} models.ParseJob
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 413 {object} middleware.Problem
+// @Failure 502 {object} middleware.Problem
+// @Failure 503 {object} middleware.Problem
 // @Router /api/v1/resume [post]
 func (h *ResumeHandler) CreateResume(c *gin.Context) {
 	var request models.ParseResumeRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(middleware.BindingProblem(err))
+		return
+	}
+	upload, err := h.sourceUpload(c, request)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Get authorization token from environment
-	authToken := os.Getenv("API_KEY")
-	if authToken == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Parse API token not configured"})
+	if async, _ := strconv.ParseBool(c.Query("async")); async {
+		job, err := h.jobs.Enqueue(c.Request.Context(), upload.Key, middleware.CurrentAPIKey(c))
+		if err != nil {
+			c.Error(fmt.Errorf("failed to queue parse job: %w", err))
+			return
+		}
+		c.Header("Location", fmt.Sprintf("/api/v1/resume/jobs/%d", job.ID))
+		c.JSON(http.StatusAccepted, job)
 		return
 	}
 
-	// Make HTTP request to parse endpoint
-	parseURL := fmt.Sprintf("http://localhost:8000/resume/parse?fileName=%s", url.QueryEscape(request.FileName))
-	
-	httpReq, err := http.NewRequest("GET", parseURL, nil)
+	parsed, err := h.parser.Parse(c.Request.Context(), upload.Key)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		respondUpstreamError(c, err, "The resume could not be parsed")
+		return
+	}
+
+	// Create resume record in database
+	resume := models.Resume{
+		UserID:    parsed.SessionID,
+		OwnerID:   upload.OwnerID,
+		RawText:   parsed.TextContent,
+		Metadata:  parsed.Metadata,
+		SourceKey: upload.Key,
+	}
+
+	if err := h.resumes.Create(c.Request.Context(), &resume, resumeChange(c, models.ResumeCreated)); err != nil {
+		c.Error(fmt.Errorf("failed to save resume: %w", err))
 		return
 	}
-	
-	// Set headers
-	httpReq.Header.Set("Accept", "application/json")
-	httpReq.Header.Set("Authorization", authToken)
-
-	// Make the request
-	client := &http.Client{Timeout: 40 * time.Second}
-	resp, err := client.Do(httpReq)
+	metrics.ResumesCreated.Inc()
+
+	// Return the response
+	c.JSON(http.StatusCreated, gin.H{
+		"status_code": http.StatusCreated,
+		"session_id":  parsed.SessionID,
+	})
+}
+
+// ResumeListResponse is a page of resumes
+type ResumeListResponse struct {
+	Items []models.ResumeSummary `json:"items"`
+	PageMeta
+}
+
+var resumeSortFields = map[string]string{
+	"id":         "id",
+	"user_id":    "user_id",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// metadataKeyPattern restricts metadata filter keys to plain identifiers
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// ListResumes godoc
+// @Summary List resumes
+// @Description Get a paginated list of resumes. Metadata can be filtered with metadata.<key>=<value> query parameters, which match when the top-level key equals or (for arrays) contains the value.
+// @Tags resume
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "API Key"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param user_id query string false "Only resumes of this user"
+// @Param schema_status query string false "Only resumes whose metadata did (valid) or did not (invalid) fit the typed schema" Enums(valid, invalid)
+// @Param created_after query string false "Created at or after (RFC 3339)"
+// @Param created_before query string false "Created before (RFC 3339)"
+// @Param sort query string false "Comma-separated sort fields (id, user_id, created_at, updated_at); prefix with - for descending" default(-id)
+// @Param include_raw_text query bool false "Include the raw resume text"
+// @Success 200 {object} ResumeListResponse
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Router /api/v1/resume [get]
+func (h *ResumeHandler) ListResumes(c *gin.Context) {
+	page, err := parsePagination(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to call parse API: %v", err)})
+		c.Error(err)
 		return
 	}
-	defer resp.Body.Close()
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	sort, err := parseSort(c.Query("sort"), resumeSortFields, []repository.SortField{{Column: "id", Desc: true}})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response"})
+		c.Error(err)
 		return
 	}
-	// Parse the JSON response
-	var parseResponse struct {
-		TextContent  string `json:"text_content"`
-		SessionId 	 string `json:"session_id"`
-		Metadata 	 models.JSONB `json:"metadata"`
+
+	filter := repository.ResumeFilter{
+		UserID:       c.Query("user_id"),
+		SchemaStatus: c.Query("schema_status"),
+		Metadata:     map[string][]string{},
 	}
-	if err := json.Unmarshal(body, &parseResponse); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse response"})
+	if filter.SchemaStatus != "" && filter.SchemaStatus != models.SchemaValid && filter.SchemaStatus != models.SchemaInvalid {
+		c.Error(middleware.InvalidField("schema_status", "must be valid or invalid"))
 		return
 	}
-
-	// Create resume record in database
-	resume := models.Resume{
-		UserID:  parseResponse.SessionId,
-		RawText:  parseResponse.TextContent,
-		Metadata: parseResponse.Metadata,
+	if filter.Created, err = parseTimeRange(c, "created"); err != nil {
+		c.Error(err)
+		return
+	}
+	filter.IncludeRawText, _ = strconv.ParseBool(c.Query("include_raw_text"))
+	for param, values := range c.Request.URL.Query() {
+		key, ok := strings.CutPrefix(param, "metadata.")
+		if !ok {
+			continue
+		}
+		if !metadataKeyPattern.MatchString(key) {
+			c.Error(middleware.InvalidField(param, "must name a metadata key made of letters, digits and underscores"))
+			return
+		}
+		filter.Metadata[key] = append(filter.Metadata[key], values...)
 	}
-	
-	if err := h.db.Create(&resume).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save resume to database"})
+
+	resumes, total, err := h.resumes.List(c.Request.Context(), filter, page.options(sort))
+	if err != nil {
+		c.Error(err)
 		return
 	}
-	
-	// Return the response
-	c.JSON(resp.StatusCode, gin.H{
-		"status_code": resp.StatusCode,
-		"session_id": parseResponse.SessionId,
+
+	c.JSON(http.StatusOK, ResumeListResponse{
+		Items:    resumes,
+		PageMeta: page.meta(c, total),
 	})
 }
 
+// GetParseJob godoc
+// @Summary Get a resume parse job
+// @Description Get the status of a background parse job and, once it has succeeded, the ID of the created resume. Only jobs queued under the API key's owner are found, except with admin keys.
+// @Tags resume
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "API Key"
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.ParseJob
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Router /api/v1/resume/jobs/{id} [get]
+func (h *ResumeHandler) GetParseJob(c *gin.Context) {
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	job, err := h.jobs.Get(c.Request.Context(), id, middleware.CurrentAPIKey(c))
+	if err != nil {
+		c.Error(lookupError(err, "Parse job not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
 // GetResume godoc
 // @Summary Get a resume by ID
 // @Description Get a resume by its ID
@@ -125,13 +234,21 @@ func (h *ResumeHandler) CreateResume(c *gin.Context) {
 // @Param Authorization header string true "API Key"
 // @Param id path string true "Resume ID"
 // @Success 200 {object} models.Resume
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
 // @Router /api/v1/resume/{id} [get]
 func (h *ResumeHandler) GetResume(c *gin.Context) {
-	id := c.Param("id")
-	var resume models.Resume
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-	if err := h.db.First(&resume, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
+	resume, err := h.resumes.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(lookupError(err, "Resume not found"))
 		return
 	}
 
@@ -140,33 +257,48 @@ func (h *ResumeHandler) GetResume(c *gin.Context) {
 
 // UpdateResume godoc
 // @Summary Update a resume
-// @Description Update a resume by its ID
+// @Description Replace the text and metadata of a resume. Metadata keys left out of the request are removed. The new text and metadata are recorded as the resume's next version.
 // @Tags resume
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "API Key"
 // @Param id path string true "Resume ID"
-// @Param resume body models.Resume true "Resume Data"
+// @Param resume body models.UpdateResumeRequest true "Resume Data"
 // @Success 200 {object} models.Resume
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Failure 413 {object} middleware.Problem
 // @Router /api/v1/resume/{id} [put]
 func (h *ResumeHandler) UpdateResume(c *gin.Context) {
-	id := c.Param("id")
-	var resume models.Resume
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-	if err := h.db.First(&resume, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
+	var request models.UpdateResumeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(middleware.BindingProblem(err))
 		return
 	}
 
-	if err := c.ShouldBindJSON(&resume); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	resume, err := h.resumes.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(lookupError(err, "Resume not found"))
 		return
 	}
 
+	// Only the content is editable; the owner, source file and schema
+	// fields are set by the server
+	resume.RawText = request.RawText
+	resume.Metadata = request.Metadata
 	resume.UpdatedAt = time.Now()
+	resume.ApplySchema()
 
-	if err := h.db.Save(&resume).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.resumes.Update(c.Request.Context(), resume, resumeChange(c, models.ResumeUpdated)); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -175,25 +307,27 @@ func (h *ResumeHandler) UpdateResume(c *gin.Context) {
 
 // DeleteResume godoc
 // @Summary Delete a resume
-// @Description Delete a resume by its ID
+// @Description Delete a resume by its ID, together with its versions
 // @Tags resume
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "API Key"
 // @Param id path string true "Resume ID"
 // @Success 204 "No Content"
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
 // @Router /api/v1/resume/{id} [delete]
 func (h *ResumeHandler) DeleteResume(c *gin.Context) {
-	id := c.Param("id")
-	var resume models.Resume
-
-	if err := h.db.First(&resume, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	if err := h.db.Delete(&resume).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.resumes.Delete(c.Request.Context(), id); err != nil {
+		c.Error(lookupError(err, "Resume not found"))
 		return
 	}
 
@@ -208,51 +342,16 @@ func (h *ResumeHandler) DeleteResume(c *gin.Context) {
 // @Produce json
 // @Param Authorization header string true "API Key"
 // @Success 200 {object} models.Resume
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
 // @Router /api/v1/resume/latest [get]
 func (h *ResumeHandler) LatestResume(c *gin.Context) {
-	var resume models.Resume
-
-	if err := h.db.Order("id desc").First(&resume).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	resume, err := h.resumes.Latest(c.Request.Context())
+	if err != nil {
+		c.Error(lookupError(err, "No resumes have been created yet"))
 		return
 	}
 
 	c.JSON(http.StatusOK, resume)
 }
-
-// GetSignedURL godoc
-// @Summary Get a presigned URL for uploading a resume
-// @Description Get a presigned URL for uploading a resume to S3
-// @Tags resume
-// @Accept json
-// @Produce json
-// @Param Authorization header string true "API Key"
-// @Param filename query string true "Filename for the resume"
-// @Success 200 {object} map[string]string
-// @Router /api/v1/resume/getSignedUrl [get]
-func (h *ResumeHandler) GetSignedURL(c *gin.Context) {
-	if h.s3Service == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "S3 service is not available"})
-		return
-	}
-
-	filename := c.Query("filename")
-	if filename == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "filename is required"})
-		return
-	}
-
-	key := "resumes/" + filename
-
-	// Get presigned URL
-	url, err := h.s3Service.GetPresignedURL(key)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"url": url,
-		"key": key,
-	})
-} 
\ No newline at end of file
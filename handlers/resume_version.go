@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-server/diff"
+	"go-server/middleware"
+	"go-server/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResumeVersionListResponse is a page of resume versions
+type ResumeVersionListResponse struct {
+	Items []models.ResumeVersionSummary `json:"items"`
+	PageMeta
+}
+
+// ResumeDiffResponse compares two versions of a resume
+type ResumeDiffResponse struct {
+	ResumeID uint `json:"resume_id" example:"1"`
+	From     int  `json:"from" example:"1"`
+	To       int  `json:"to" example:"3"`
+	// RawText is the unified diff of the text, empty when it is unchanged
+	RawText  string        `json:"raw_text"`
+	Metadata []diff.Change `json:"metadata"`
+}
+
+// resumeChange attributes a change of a resume to the request's API key
+func resumeChange(c *gin.Context, action string) models.ResumeChange {
+	change := models.ResumeChange{Action: action}
+	if key := middleware.CurrentAPIKey(c); key != nil {
+		change.APIKeyID = &key.ID
+		change.ChangedBy = key.OwnerID
+	}
+	return change
+}
+
+// parseVersion reads a version number path parameter
+func parseVersion(c *gin.Context, name string) (int, error) {
+	version, err := parseID(c, name)
+	return int(version), err
+}
+
+// queryVersion reads a required version number query parameter
+func queryVersion(c *gin.Context, name string) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return 0, middleware.InvalidField(name, "is required")
+	}
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < 1 {
+		return 0, middleware.InvalidField(name, "must be a positive integer")
+	}
+	return version, nil
+}
+
+// ListVersions godoc
+// @Summary List resume versions
+// @Description Get the versions of a resume, oldest first, without their raw text. A version is recorded every time the resume is created, updated or restored.
+// @Tags resume
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "API Key"
+// @Param id path string true "Resume ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {object} ResumeVersionListResponse
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Router /api/v1/resume/{id}/versions [get]
+func (h *ResumeHandler) ListVersions(c *gin.Context) {
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	page, err := parsePagination(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if _, err := h.resumes.Get(c.Request.Context(), id); err != nil {
+		c.Error(lookupError(err, "Resume not found"))
+		return
+	}
+
+	versions, total, err := h.resumes.Versions(c.Request.Context(), id, page.options(nil))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ResumeVersionListResponse{
+		Items:    versions,
+		PageMeta: page.meta(c, total),
+	})
+}
+
+// GetVersion godoc
+// @Summary Get a resume version
+// @Description Get the text and metadata a resume had in one version
+// @Tags resume
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "API Key"
+// @Param id path string true "Resume ID"
+// @Param version path int true "Version number"
+// @Success 200 {object} models.ResumeVersion
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Router /api/v1/resume/{id}/versions/{version} [get]
+func (h *ResumeHandler) GetVersion(c *gin.Context) {
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	number, err := parseVersion(c, "version")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	version, err := h.resumes.Version(c.Request.Context(), id, number)
+	if err != nil {
+		c.Error(lookupError(err, "Resume version not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+// RestoreVersion godoc
+// @Summary Restore a resume version
+// @Description Replace a resume's text and metadata with those of an earlier version. The restore is recorded as a new version, so it can be undone.
+// @Tags resume
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "API Key"
+// @Param id path string true "Resume ID"
+// @Param version path int true "Version number to restore"
+// @Success 200 {object} models.Resume
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Router /api/v1/resume/{id}/versions/{version}/restore [post]
+func (h *ResumeHandler) RestoreVersion(c *gin.Context) {
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	number, err := parseVersion(c, "version")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resume, err := h.resumes.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(lookupError(err, "Resume not found"))
+		return
+	}
+	version, err := h.resumes.Version(c.Request.Context(), id, number)
+	if err != nil {
+		c.Error(lookupError(err, "Resume version not found"))
+		return
+	}
+
+	resume.RawText = version.RawText
+	resume.Metadata = version.Metadata
+	resume.UpdatedAt = time.Now()
+
+	change := resumeChange(c, models.ResumeRestored)
+	change.RestoredFrom = &version.Version
+	if err := h.resumes.Update(c.Request.Context(), resume, change); err != nil {
+		c.Error(fmt.Errorf("failed to restore resume version: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, resume)
+}
+
+// DiffVersions godoc
+// @Summary Compare two resume versions
+// @Description Get a unified line diff of the raw text and a key-path diff of the metadata between two versions of a resume. Texts that differ in more than 1000 lines are shown as replaced as a whole. Array elements are aligned, so inserting a skill or a position is reported as one addition; indices in removal paths count in the from version, all others in the to version.
+// @Tags resume
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "API Key"
+// @Param id path string true "Resume ID"
+// @Param from query int true "Version to compare from"
+// @Param to query int true "Version to compare to"
+// @Success 200 {object} ResumeDiffResponse
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Router /api/v1/resume/{id}/diff [get]
+func (h *ResumeHandler) DiffVersions(c *gin.Context) {
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	from, err := queryVersion(c, "from")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	to, err := queryVersion(c, "to")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	fromVersion, err := h.resumes.Version(c.Request.Context(), id, from)
+	if err != nil {
+		c.Error(lookupError(err, fmt.Sprintf("Resume version %d not found", from)))
+		return
+	}
+	toVersion, err := h.resumes.Version(c.Request.Context(), id, to)
+	if err != nil {
+		c.Error(lookupError(err, fmt.Sprintf("Resume version %d not found", to)))
+		return
+	}
+
+	c.JSON(http.StatusOK, ResumeDiffResponse{
+		ResumeID: id,
+		From:     from,
+		To:       to,
+		RawText:  diff.Lines(fmt.Sprintf("version %d", from), fmt.Sprintf("version %d", to), fromVersion.RawText, toVersion.RawText),
+		Metadata: diff.JSON(fromVersion.Metadata, toVersion.Metadata),
+	})
+}
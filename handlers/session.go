@@ -1,29 +1,59 @@
 package handlers
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
+
+	"go-server/metrics"
+	"go-server/middleware"
+	"go-server/models"
+	"go-server/repository"
+	"go-server/services"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type SessionHandler struct {
-	db *gorm.DB
+	sessions repository.SessionRepository
+	resumes  repository.ResumeRepository
+	chat     *services.ChatClient
+	ttl      time.Duration
 }
 
-func NewSessionHandler(db *gorm.DB) *SessionHandler {
+// NewSessionHandler creates a handler whose sessions stay usable for ttl
+// after their last activity. Resumes are looked up to validate the resume
+// a session is linked to.
+func NewSessionHandler(sessions repository.SessionRepository, resumes repository.ResumeRepository, chat *services.ChatClient, ttl time.Duration) *SessionHandler {
 	return &SessionHandler{
-		db: db,
+		sessions: sessions,
+		resumes:  resumes,
+		chat:     chat,
+		ttl:      ttl,
 	}
 }
 
 // SessionChatRequest represents the request body for session chat
 type SessionChatRequest struct {
 	SessionID string `json:"sessionId" binding:"required"`
-	Question  string `json:"question" binding:"required"`
+	// UserID defaults to the API key's owner; only admin keys may name
+	// another user
+	UserID   string `json:"userId"`
+	Question string `json:"question" binding:"required"`
+}
+
+// InitSessionResponse represents the response for session initialization
+type InitSessionResponse struct {
+	SessionID string    `json:"sessionId"`
+	ResumeID  *uint     `json:"resumeId,omitempty"`
+	Status    string    `json:"status"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Message   string    `json:"message"`
 }
 
 // SessionChatResponse represents the response for session chat
@@ -34,74 +64,269 @@ type SessionChatResponse struct {
 
 // InitSession godoc
 // @Summary Initialize a new session
-// @Description Initialize a new chat session
+// @Description Initialize a new chat session owned by the API key's owner, optionally linked to a resume of the same owner. Admin keys may start a session for another user with userId and link any resume.
 // @Tags session
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "API Key"
-// @Success 200 {object} map[string]interface{}
+// @Param userId query string false "Owner of the session, defaults to the API key's owner"
+// @Param resumeId query int false "Resume to discuss in the session"
+// @Success 201 {object} InitSessionResponse
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
 // @Router /api/v1/session/init [get]
 func (h *SessionHandler) InitSession(c *gin.Context) {
-	// Static/dummy response for now
-	c.JSON(http.StatusOK, gin.H{
-		"sessionId": "session-12345",
-		"status":    "initialized",
-		"message":   "Session initialized successfully",
+	userID, err := sessionOwner(c, c.Query("userId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var resumeID *uint
+	if raw := c.Query("resumeId"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.Error(middleware.InvalidField("resumeId", "must be a positive integer"))
+			return
+		}
+		resume, err := h.resumes.Get(c.Request.Context(), uint(id))
+		if err != nil {
+			c.Error(lookupError(err, "Resume not found"))
+			return
+		}
+		// Another owner's resume is reported as missing so that its
+		// existence is not revealed
+		if resume.OwnerID != userID && !middleware.CurrentAPIKey(c).IsAdmin() {
+			c.Error(middleware.NewProblem(http.StatusNotFound, middleware.CodeNotFound, "Resume not found"))
+			return
+		}
+		resumeID = &resume.ID
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		c.Error(fmt.Errorf("failed to generate session ID: %w", err))
+		return
+	}
+
+	now := time.Now()
+	session := models.Session{
+		ID:           id,
+		OwnerID:      userID,
+		ResumeID:     resumeID,
+		Status:       models.SessionStatusActive,
+		LastActiveAt: now,
+		ExpiresAt:    now.Add(h.ttl),
+	}
+	if err := h.sessions.Create(c.Request.Context(), &session); err != nil {
+		c.Error(fmt.Errorf("failed to create session: %w", err))
+		return
+	}
+	metrics.SessionsStarted.Inc()
+
+	c.JSON(http.StatusCreated, InitSessionResponse{
+		SessionID: session.ID,
+		ResumeID:  session.ResumeID,
+		Status:    session.Status,
+		ExpiresAt: session.ExpiresAt,
+		Message:   "Session initialized successfully",
 	})
 }
 
+// newSessionID returns a random, unguessable session identifier
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "session-" + hex.EncodeToString(buf), nil
+}
+
+// sessionOwner returns the user a session request acts for: the API key's
+// owner, or the requested user for admin keys. Other keys may only name
+// their own owner.
+func sessionOwner(c *gin.Context, requested string) (string, error) {
+	key := middleware.CurrentAPIKey(c)
+	if requested == "" || requested == key.OwnerID {
+		return key.OwnerID, nil
+	}
+	if !key.IsAdmin() {
+		return "", middleware.NewProblem(http.StatusForbidden, middleware.CodeForbidden, "API key cannot act for another user")
+	}
+	return requested, nil
+}
+
+// findOwnedSession fetches a session owned by the user the request acts for
+// (see sessionOwner), rejecting unknown and
+// foreign sessions with not_found and forbidden problems respectively
+func (h *SessionHandler) findOwnedSession(c *gin.Context, sessionID, requested string) (*models.Session, error) {
+	userID, err := sessionOwner(c, requested)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := h.sessions.Get(c.Request.Context(), sessionID)
+	if err != nil {
+		return nil, lookupError(err, "Session not found")
+	}
+
+	if session.OwnerID != userID {
+		return nil, middleware.NewProblem(http.StatusForbidden, middleware.CodeForbidden, "Session belongs to another user")
+	}
+
+	return session, nil
+}
+
+// loadSession fetches an active session owned by the requested user and extends its
+// expiry. Expired sessions are rejected with a session_expired problem (410)
+// in addition to the checks of findOwnedSession.
+func (h *SessionHandler) loadSession(c *gin.Context, sessionID, requested string) (*models.Session, error) {
+	session, err := h.findOwnedSession(c, sessionID, requested)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if session.Expired(now) {
+		if session.Status != models.SessionStatusExpired {
+			session.Status = models.SessionStatusExpired
+			h.sessions.Update(c.Request.Context(), session)
+		}
+		return nil, middleware.NewProblem(http.StatusGone, middleware.CodeSessionExpired, "Session has expired")
+	}
+
+	session.LastActiveAt = now
+	session.ExpiresAt = now.Add(h.ttl)
+	if err := h.sessions.Update(c.Request.Context(), session); err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	return session, nil
+}
+
 // ChatSession godoc
 // @Summary Chat with a session
-// @Description Send a question to a chat session and get an answer
+// @Description Send a question to a chat session and get an answer. The session must exist, belong to the API key's owner (or userId for admin keys) and not be expired.
 // @Tags session
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "API Key"
 // @Param request body SessionChatRequest true "Chat Request"
 // @Success 200 {object} SessionChatResponse
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Failure 410 {object} middleware.Problem
+// @Failure 502 {object} middleware.Problem
+// @Failure 503 {object} middleware.Problem
 // @Router /api/v1/session/chat [post]
 func (h *SessionHandler) ChatSession(c *gin.Context) {
 	var request SessionChatRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(middleware.BindingProblem(err))
 		return
 	}
 
-	// Prepare request body
-	reqBody := map[string]string{
-		"session_id": request.SessionID,
-		"message":   request.Question,
-	}
-	jsonBody, err := json.Marshal(reqBody)
+	session, err := h.loadSession(c, request.SessionID, request.UserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		c.Error(err)
 		return
 	}
-	// Make POST request to chat endpoint
-	resp, err := http.Post("http://localhost:8000/session/chat", "application/json", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to call chat API: %v", err)})
-		return
-	}
-	defer resp.Body.Close()
 
-	// Parse response
-	var chatResponse struct {
-		Answer    string `json:"answer"`
-		SessionID string `json:"session_id"`
-		Status    string `json:"status"`
+	started := time.Now()
+	answer, err := h.chat.Ask(c.Request.Context(), session.ID, request.Question)
+	latency := time.Since(started).Milliseconds()
+
+	turns := []models.ChatMessage{{
+		SessionID: session.ID,
+		Role:      models.ChatRoleUser,
+		Content:   request.Question,
+		LatencyMs: latency,
+	}}
+	if answer != nil {
+		turns[0].UpstreamStatus = answer.StatusCode
 	}
+	if err == nil {
+		turns = append(turns, models.ChatMessage{
+			SessionID:      session.ID,
+			Role:           models.ChatRoleAssistant,
+			Content:        answer.Answer,
+			UpstreamStatus: answer.StatusCode,
+			LatencyMs:      latency,
+		})
+	}
+	h.saveMessages(c.Request.Context(), turns...)
 
-	if err := json.NewDecoder(resp.Body).Decode(&chatResponse); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse response"})
+	if err != nil {
+		respondUpstreamError(c, err, "The chat service failed to answer")
 		return
 	}
+	metrics.ChatTurns.WithLabelValues("sync").Inc()
 
 	// Return chat response
 	response := SessionChatResponse{
-		SessionID: chatResponse.SessionID,
-		Answer:    chatResponse.Answer,
+		SessionID: answer.SessionID,
+		Answer:    answer.Answer,
 	}
 
 	c.JSON(http.StatusOK, response)
-}
\ No newline at end of file
+}
+
+// saveMessages stores chat turns in a single transaction. Failures are
+// logged rather than surfaced so that the caller still gets its answer.
+func (h *SessionHandler) saveMessages(ctx context.Context, messages ...models.ChatMessage) {
+	if err := h.sessions.AddMessages(ctx, messages...); err != nil {
+		slog.ErrorContext(ctx, "failed to save chat messages", "error", err)
+	}
+}
+
+// ChatMessageListResponse is a page of chat messages
+type ChatMessageListResponse struct {
+	Items []models.ChatMessage `json:"items"`
+	PageMeta
+}
+
+// ListMessages godoc
+// @Summary List session messages
+// @Description Get the chat history of a session, oldest first. Expired sessions can still be read by their owner.
+// @Tags session
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "API Key"
+// @Param id path string true "Session ID"
+// @Param userId query string false "Owner of the session, defaults to the API key's owner"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {object} ChatMessageListResponse
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Router /api/v1/session/{id}/messages [get]
+func (h *SessionHandler) ListMessages(c *gin.Context) {
+	page, err := parsePagination(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	session, err := h.findOwnedSession(c, c.Param("id"), c.Query("userId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	messages, total, err := h.sessions.ListMessages(c.Request.Context(), session.ID, page.options(nil))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ChatMessageListResponse{
+		Items:    messages,
+		PageMeta: page.meta(c, total),
+	})
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-server/metrics"
+	"go-server/middleware"
+	"go-server/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChatSessionStream godoc
+// @Summary Chat with a session, streaming the answer
+// @Description Send a question to a chat session and receive the answer as Server-Sent Events. "token" events carry answer fragments as they arrive, a final "done" event carries the full answer and an "error" event reports an upstream failure. Disconnecting cancels the upstream request.
+// @Tags session
+// @Accept json
+// @Produce text/event-stream
+// @Param Authorization header string true "API Key"
+// @Param request body SessionChatRequest true "Chat Request"
+// @Success 200 {string} string "Server-Sent Events stream"
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Failure 410 {object} middleware.Problem
+// @Failure 502 {object} middleware.Problem
+// @Failure 503 {object} middleware.Problem
+// @Router /api/v1/session/chat/stream [post]
+func (h *SessionHandler) ChatSessionStream(c *gin.Context) {
+	var request SessionChatRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(middleware.BindingProblem(err))
+		return
+	}
+
+	session, err := h.loadSession(c, request.SessionID, request.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Tie the upstream request to the client connection so that a
+	// disconnect cancels it
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	started := time.Now()
+	userTurn := models.ChatMessage{
+		SessionID: session.ID,
+		Role:      models.ChatRoleUser,
+		Content:   request.Question,
+	}
+
+	resp, err := h.chat.Stream(ctx, session.ID, request.Question)
+	if err != nil {
+		userTurn.LatencyMs = time.Since(started).Milliseconds()
+		h.saveMessages(c.Request.Context(), userTurn)
+		respondUpstreamError(c, err, "The chat service failed to answer")
+		return
+	}
+	defer resp.Body.Close()
+
+	userTurn.UpstreamStatus = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		userTurn.LatencyMs = time.Since(started).Milliseconds()
+		h.saveMessages(c.Request.Context(), userTurn)
+		problem := middleware.NewProblem(http.StatusBadGateway, middleware.CodeUpstreamError, "The chat service failed to answer")
+		problem.UpstreamStatus = resp.StatusCode
+		c.Error(problem)
+		return
+	}
+
+	// A streamed answer lasts as long as the chat service takes to produce
+	// it, so the server's write timeout must not cut it off; the stream
+	// still ends when the client goes away
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.WarnContext(ctx, "failed to clear the stream write deadline", "error", err)
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	var answer strings.Builder
+	tokens := make(chan string)
+	streamErr := make(chan error, 1)
+	go func() {
+		defer close(tokens)
+		streamErr <- readChatStream(resp.Body, func(token string) bool {
+			select {
+			case tokens <- token:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	clientGone := c.Stream(func(w io.Writer) bool {
+		select {
+		case token, ok := <-tokens:
+			if !ok {
+				return false
+			}
+			answer.WriteString(token)
+			c.SSEvent("token", token)
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+
+	latency := time.Since(started).Milliseconds()
+	userTurn.LatencyMs = latency
+	turns := []models.ChatMessage{userTurn}
+	if answer.Len() > 0 {
+		turns = append(turns, models.ChatMessage{
+			SessionID:      session.ID,
+			Role:           models.ChatRoleAssistant,
+			Content:        answer.String(),
+			UpstreamStatus: resp.StatusCode,
+			LatencyMs:      latency,
+		})
+	}
+	h.saveMessages(context.WithoutCancel(c.Request.Context()), turns...)
+
+	// The client went away; nothing left to send
+	if clientGone || ctx.Err() != nil {
+		return
+	}
+
+	if err := <-streamErr; err != nil {
+		c.Error(err)
+		c.SSEvent("error", middleware.ProblemFor(c, middleware.NewProblem(http.StatusBadGateway, middleware.CodeUpstreamError, "Chat stream interrupted")))
+	} else {
+		metrics.ChatTurns.WithLabelValues("stream").Inc()
+		c.SSEvent("done", SessionChatResponse{
+			SessionID: session.ID,
+			Answer:    answer.String(),
+		})
+	}
+	c.Writer.Flush()
+}
+
+// readChatStream parses an upstream SSE body, calling emit for every token
+// until the stream ends, "[DONE]" is received or emit returns false. Each
+// "data:" line carries either a JSON object with a "token" field or a raw
+// text fragment.
+func readChatStream(body io.Reader, emit func(string) bool) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimPrefix(data, " ")
+		if data == "[DONE]" {
+			return nil
+		}
+
+		token := data
+		var chunk struct {
+			Token *string `json:"token"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err == nil && chunk.Token != nil {
+			token = *chunk.Token
+		}
+		if token == "" {
+			continue
+		}
+		if !emit(token) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/config"
+	"go-server/middleware"
+	"go-server/models"
+	"go-server/repository"
+	"go-server/services"
+)
+
+// newChatUpstream fakes the chat service, echoing every question except
+// "fail", which it answers with a 500 error body
+func newChatUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			SessionID string `json:"session_id"`
+			Message   string `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path == "/session/chat/stream" {
+			// Streams "echo: <message>" in two events, pausing between
+			// them when the message is "slow"
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: echo: \n\n")
+			w.(http.Flusher).Flush()
+			if body.Message == "slow" {
+				time.Sleep(300 * time.Millisecond)
+			}
+			fmt.Fprintf(w, "data: {\"token\":%q}\n\ndata: [DONE]\n\n", body.Message)
+			return
+		}
+		if body.Message == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "model overloaded"})
+			return
+		}
+		json.NewEncoder(w).Encode(services.ChatAnswer{SessionID: body.SessionID, Answer: "echo: " + body.Message})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newSessionRouter(t *testing.T) (http.Handler, *repository.MemorySessions, *repository.MemoryResumes) {
+	t.Helper()
+
+	sessions := repository.NewMemorySessions()
+	resumes := repository.NewMemoryResumes()
+	chat := config.Default().Chat
+	chat.URL = newChatUpstream(t).URL
+	h := NewSessionHandler(sessions, resumes, services.NewChatClient(chat), time.Hour)
+	keys := fakeKeys{
+		"alice": {OwnerID: "alice", Scopes: models.ScopeSessionChat, Role: models.RoleUser},
+		"bob":   {OwnerID: "bob", Scopes: models.ScopeSessionChat, Role: models.RoleUser},
+		"admin": {OwnerID: "ops", Scopes: models.ScopeSessionChat, Role: models.RoleAdmin},
+	}
+
+	r := newTestEngine()
+	group := r.Group("/session", middleware.APIKeyAuth(keys, models.ScopeSessionChat))
+	group.GET("/init", h.InitSession)
+	group.POST("/chat", h.ChatSession)
+	group.POST("/chat/stream", h.ChatSessionStream)
+	group.GET("/:id/messages", h.ListMessages)
+	return r, sessions, resumes
+}
+
+func TestSessionChat(t *testing.T) {
+	r, _, resumes := newSessionRouter(t)
+	resume := models.Resume{UserID: "alice", OwnerID: "alice", RawText: "Go developer"}
+	if err := resumes.Create(context.Background(), &resume, models.ResumeChange{Action: models.ResumeCreated}); err != nil {
+		t.Fatal(err)
+	}
+
+	var session InitSessionResponse
+	w := serve(t, r, http.MethodGet, "/session/init?resumeId=1", "alice", nil)
+	decode(t, w, http.StatusCreated, &session)
+	if session.ResumeID == nil || *session.ResumeID != 1 || session.Status != models.SessionStatusActive {
+		t.Fatalf("session = %+v", session)
+	}
+
+	var answer SessionChatResponse
+	w = serve(t, r, http.MethodPost, "/session/chat", "alice", SessionChatRequest{SessionID: session.SessionID, Question: "hi"})
+	decode(t, w, http.StatusOK, &answer)
+	if answer.Answer != "echo: hi" {
+		t.Errorf("answer = %q", answer.Answer)
+	}
+
+	var messages ChatMessageListResponse
+	w = serve(t, r, http.MethodGet, "/session/"+session.SessionID+"/messages", "alice", nil)
+	decode(t, w, http.StatusOK, &messages)
+	if messages.Total != 2 || messages.Items[0].Role != models.ChatRoleUser || messages.Items[1].Content != "echo: hi" {
+		t.Errorf("messages = %+v", messages)
+	}
+
+	// The userId a client sends cannot claim another owner's session
+	w = serve(t, r, http.MethodPost, "/session/chat", "bob", SessionChatRequest{SessionID: session.SessionID, Question: "hi"})
+	expectProblem(t, w, http.StatusForbidden, middleware.CodeForbidden)
+	w = serve(t, r, http.MethodPost, "/session/chat", "bob", SessionChatRequest{SessionID: session.SessionID, UserID: "alice", Question: "hi"})
+	expectProblem(t, w, http.StatusForbidden, middleware.CodeForbidden)
+	w = serve(t, r, http.MethodGet, "/session/"+session.SessionID+"/messages?userId=alice", "bob", nil)
+	expectProblem(t, w, http.StatusForbidden, middleware.CodeForbidden)
+	w = serve(t, r, http.MethodGet, "/session/init?userId=alice", "bob", nil)
+	expectProblem(t, w, http.StatusForbidden, middleware.CodeForbidden)
+
+	// Resumes of another owner cannot be linked
+	w = serve(t, r, http.MethodGet, "/session/init?resumeId=1", "bob", nil)
+	expectProblem(t, w, http.StatusNotFound, middleware.CodeNotFound)
+	w = serve(t, r, http.MethodGet, "/session/init?resumeId=1", "admin", nil)
+	decode(t, w, http.StatusCreated, nil)
+
+	// Admin keys may act for any user
+	w = serve(t, r, http.MethodGet, "/session/"+session.SessionID+"/messages?userId=alice", "admin", nil)
+	decode(t, w, http.StatusOK, &messages)
+	w = serve(t, r, http.MethodGet, "/session/"+session.SessionID+"/messages", "admin", nil)
+	expectProblem(t, w, http.StatusForbidden, middleware.CodeForbidden)
+}
+
+func TestSessionChatStreamOutlivesWriteTimeout(t *testing.T) {
+	r, _, _ := newSessionRouter(t)
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	var session InitSessionResponse
+	decode(t, serve(t, r, http.MethodGet, "/session/init", "alice", nil), http.StatusCreated, &session)
+
+	body := strings.NewReader(fmt.Sprintf(`{"sessionId":%q,"question":"slow"}`, session.SessionID))
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/session/chat/stream", body)
+	req.Header.Set("Authorization", "Bearer alice")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	events, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("stream cut off after %q: %v", events, err)
+	}
+	if !strings.Contains(string(events), "event:done") || !strings.Contains(string(events), `"answer":"echo: slow"`) {
+		t.Errorf("stream = %q, want a done event with the full answer", events)
+	}
+}
+
+func TestSessionChatUpstreamError(t *testing.T) {
+	r, sessions, _ := newSessionRouter(t)
+
+	var session InitSessionResponse
+	w := serve(t, r, http.MethodGet, "/session/init", "alice", nil)
+	decode(t, w, http.StatusCreated, &session)
+
+	w = serve(t, r, http.MethodPost, "/session/chat", "alice", SessionChatRequest{SessionID: session.SessionID, Question: "fail"})
+	problem := expectProblem(t, w, http.StatusBadGateway, middleware.CodeUpstreamError)
+	if problem.UpstreamStatus != http.StatusInternalServerError {
+		t.Errorf("upstream_status = %d, want 500", problem.UpstreamStatus)
+	}
+
+	messages, total, err := sessions.ListMessages(context.Background(), session.SessionID, repository.ListOptions{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || messages[0].Role != models.ChatRoleUser || messages[0].UpstreamStatus != http.StatusInternalServerError {
+		t.Errorf("messages = %+v, want only the question", messages)
+	}
+}
+
+func TestSessionInitRejectsBadRequests(t *testing.T) {
+	r, _, _ := newSessionRouter(t)
+
+	w := serve(t, r, http.MethodGet, "/session/init", "", nil)
+	expectProblem(t, w, http.StatusUnauthorized, middleware.CodeUnauthorized)
+	w = serve(t, r, http.MethodGet, "/session/init?resumeId=x", "alice", nil)
+	expectProblem(t, w, http.StatusBadRequest, middleware.CodeValidationFailed)
+	w = serve(t, r, http.MethodGet, "/session/init?resumeId=7", "alice", nil)
+	expectProblem(t, w, http.StatusNotFound, middleware.CodeNotFound)
+}
+
+func TestSessionChatExpired(t *testing.T) {
+	r, sessions, _ := newSessionRouter(t)
+	past := time.Now().Add(-time.Minute)
+	session := models.Session{
+		ID:           "session-old",
+		OwnerID:      "alice",
+		Status:       models.SessionStatusActive,
+		LastActiveAt: past.Add(-time.Hour),
+		ExpiresAt:    past,
+	}
+	if err := sessions.Create(context.Background(), &session); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(t, r, http.MethodPost, "/session/chat", "alice", SessionChatRequest{SessionID: "session-old", Question: "hi"})
+	expectProblem(t, w, http.StatusGone, middleware.CodeSessionExpired)
+	if stored, _ := sessions.Get(context.Background(), "session-old"); stored.Status != models.SessionStatusExpired {
+		t.Errorf("status = %q, want the session marked expired", stored.Status)
+	}
+
+	w = serve(t, r, http.MethodPost, "/session/chat", "alice", SessionChatRequest{SessionID: "session-new", Question: "hi"})
+	expectProblem(t, w, http.StatusNotFound, middleware.CodeNotFound)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go-server/middleware"
+	"go-server/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StorageHandler answers the upload and download URLs signed by the local
+// storage backend. The signature stands in for an API key.
+type StorageHandler struct {
+	store *services.LocalStorage
+}
+
+func NewStorageHandler(store *services.LocalStorage) *StorageHandler {
+	return &StorageHandler{store: store}
+}
+
+// verify checks the signed URL of the request and returns its storage key.
+// Uploads must also send the content type and length that were signed.
+func (h *StorageHandler) verify(c *gin.Context) (string, error) {
+	request := services.SignedRequest{
+		Method: c.Request.Method,
+		Key:    strings.TrimPrefix(c.Param("key"), "/"),
+	}
+	if request.Method == http.MethodPut {
+		request.ContentType = c.GetHeader("Content-Type")
+		request.ContentLength = c.Request.ContentLength
+	}
+	if err := h.store.Verify(request, c.Query("expires"), c.Query("signature")); err != nil {
+		return "", middleware.NewProblem(http.StatusForbidden, middleware.CodeForbidden, "The storage URL is invalid or has expired")
+	}
+	return request.Key, nil
+}
+
+// UploadObject godoc
+// @Summary Upload a file to local storage
+// @Description Store the request body under key. Only reachable through a URL returned by getSignedUrl when the local storage backend is configured, sending the headers returned with it.
+// @Tags storage
+// @Accept octet-stream
+// @Produce json
+// @Param key path string true "Storage key"
+// @Param expires query int true "Expiry of the URL (Unix time)"
+// @Param signature query string true "URL signature"
+// @Success 200 {object} services.ObjectInfo
+// @Failure 400 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Router /storage/{key} [put]
+func (h *StorageHandler) UploadObject(c *gin.Context) {
+	key, err := h.verify(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// The signature pins Content-Length; never read past it
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, c.Request.ContentLength)
+	info, err := h.store.Put(c.Request.Context(), key, c.GetHeader("Content-Type"), body)
+	if errors.Is(err, services.ErrInvalidStorageKey) {
+		c.Error(middleware.InvalidField("key", "must be a relative path without . or .. segments"))
+		return
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		c.Error(middleware.NewProblem(http.StatusBadRequest, middleware.CodeMalformedRequest, "The body is shorter than its Content-Length"))
+		return
+	}
+	if err != nil {
+		c.Error(fmt.Errorf("failed to store object: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// DownloadObject godoc
+// @Summary Download a file from local storage
+// @Description Serve the file stored under key. Only reachable through a signed download URL when the local storage backend is configured.
+// @Tags storage
+// @Produce octet-stream
+// @Param key path string true "Storage key"
+// @Param expires query int true "Expiry of the URL (Unix time)"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Router /storage/{key} [get]
+func (h *StorageHandler) DownloadObject(c *gin.Context) {
+	key, err := h.verify(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	file, info, err := h.store.Open(c.Request.Context(), key)
+	if errors.Is(err, services.ErrObjectNotFound) || errors.Is(err, services.ErrInvalidStorageKey) {
+		c.Error(middleware.NewProblem(http.StatusNotFound, middleware.CodeNotFound, "File not found"))
+		return
+	}
+	if err != nil {
+		c.Error(fmt.Errorf("failed to open object: %w", err))
+		return
+	}
+	defer file.Close()
+
+	if info.ContentType != "" {
+		c.Header("Content-Type", info.ContentType)
+	}
+	http.ServeContent(c.Writer, c.Request, "", info.LastModified, file)
+}
@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go-server/middleware"
+	"go-server/models"
+	"go-server/repository"
+	"go-server/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxFileNameLength bounds the client's name of an uploaded file
+const maxFileNameLength = 255
+
+// resumeContentTypes maps the accepted resume file extensions to the only
+// content type each may be uploaded with
+var resumeContentTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+// unsafeKeyChars matches what is replaced in the owner segment of a key
+var unsafeKeyChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// UploadSettings controls the presigned URLs handed out for resume files
+type UploadSettings struct {
+	UploadURLExpiry   time.Duration
+	DownloadURLExpiry time.Duration
+	// MaxBytes is the largest file that may be uploaded
+	MaxBytes int64
+}
+
+// UploadURLResponse is a presigned upload URL and the upload it belongs to.
+// The file must be sent with an HTTP PUT carrying exactly the listed headers.
+type UploadURLResponse struct {
+	URL       string            `json:"url" example:"https://bucket.s3.amazonaws.com/resumes/team-recruiting/4f1c2a9e0b7d4c3e8a6f5b2d1e0c9a8b.pdf?X-Amz-Signature=..."`
+	Headers   map[string]string `json:"headers"`
+	Key       string            `json:"key" example:"resumes/team-recruiting/4f1c2a9e0b7d4c3e8a6f5b2d1e0c9a8b.pdf"`
+	UploadID  uint              `json:"upload_id" example:"1"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// FileURLResponse is a presigned download URL of a resume's source file
+type FileURLResponse struct {
+	URL         string    `json:"url" example:"https://bucket.s3.amazonaws.com/resumes/cv.pdf?X-Amz-Signature=..."`
+	Key         string    `json:"key" example:"resumes/cv.pdf"`
+	Size        int64     `json:"size" example:"48213"`
+	ContentType string    `json:"content_type,omitempty" example:"application/pdf"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// storageAvailable records a service_unavailable problem unless storage is
+// configured
+func (h *ResumeHandler) storageAvailable(c *gin.Context) bool {
+	if h.storage == nil {
+		c.Error(middleware.NewProblem(http.StatusServiceUnavailable, middleware.CodeServiceUnavailable, "Resume storage is not available"))
+		return false
+	}
+	return true
+}
+
+// resumeContentType validates the name of a file to upload and returns the
+// content type it must be uploaded with
+func resumeContentType(filename string) (string, error) {
+	if filename == "" {
+		return "", middleware.InvalidField("filename", "is required")
+	}
+	if len(filename) > maxFileNameLength || strings.ContainsAny(filename, `/\`) || strings.ContainsFunc(filename, unicode.IsControl) {
+		return "", middleware.InvalidField("filename", fmt.Sprintf("must be a plain file name of at most %d bytes", maxFileNameLength))
+	}
+	contentType, ok := resumeContentTypes[strings.ToLower(path.Ext(filename))]
+	if !ok {
+		return "", middleware.InvalidField("filename", "must be a .pdf or .docx file")
+	}
+	return contentType, nil
+}
+
+// uploadKey returns a fresh storage key under the owner's prefix. The key
+// never contains the client's file name, so uploads cannot collide or
+// escape their prefix.
+func uploadKey(ownerID, ext string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	owner := unsafeKeyChars.ReplaceAllString(ownerID, "_")
+	if len(owner) > 64 {
+		owner = owner[:64]
+	}
+	if strings.Trim(owner, ".") == "" {
+		owner = "_"
+	}
+	return "resumes/" + owner + "/" + hex.EncodeToString(buf) + ext, nil
+}
+
+// GetSignedURL godoc
+// @Summary Get a presigned URL for uploading a resume
+// @Description Get a presigned URL for uploading a PDF or DOCX resume of the given size with an HTTP PUT to the configured storage (S3, an S3-compatible server or the local disk). The upload must send the returned headers, which pin the content type and length. The upload is recorded as pending; confirm it with /api/v1/resume/uploads/{id}/complete once the file has been sent.
+// @Tags resume
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "API Key"
+// @Param filename query string true "Name of the file (.pdf or .docx)"
+// @Param size query int true "Size of the file in bytes"
+// @Success 200 {object} UploadURLResponse
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 503 {object} middleware.Problem
+// @Router /api/v1/resume/getSignedUrl [get]
+func (h *ResumeHandler) GetSignedURL(c *gin.Context) {
+	if !h.storageAvailable(c) {
+		return
+	}
+
+	filename := c.Query("filename")
+	contentType, err := resumeContentType(filename)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	size, err := strconv.ParseInt(c.Query("size"), 10, 64)
+	if err != nil || size < 1 || size > h.settings.MaxBytes {
+		c.Error(middleware.InvalidField("size", fmt.Sprintf("must be between 1 and %d bytes", h.settings.MaxBytes)))
+		return
+	}
+
+	apiKey := middleware.CurrentAPIKey(c)
+	key, err := uploadKey(apiKey.OwnerID, strings.ToLower(path.Ext(filename)))
+	if err != nil {
+		c.Error(fmt.Errorf("failed to generate upload key: %w", err))
+		return
+	}
+
+	// Get presigned URL
+	presigned, err := h.storage.PresignPut(c.Request.Context(), key, services.UploadPolicy{
+		ContentType:   contentType,
+		ContentLength: size,
+		Expires:       h.settings.UploadURLExpiry,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	upload := models.ResumeUpload{
+		Key:         key,
+		FileName:    filename,
+		OwnerID:     apiKey.OwnerID,
+		APIKeyID:    apiKey.ID,
+		Status:      models.UploadPending,
+		Size:        size,
+		ContentType: contentType,
+		ExpiresAt:   time.Now().Add(h.settings.UploadURLExpiry),
+	}
+	if err := h.uploads.Create(c.Request.Context(), &upload); err != nil {
+		c.Error(fmt.Errorf("failed to record upload: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, UploadURLResponse{
+		URL:       presigned.URL,
+		Headers:   presigned.Headers,
+		Key:       key,
+		UploadID:  upload.ID,
+		ExpiresAt: upload.ExpiresAt,
+	})
+}
+
+// sourceUpload resolves the file a resume is parsed from to a completed
+// upload of the API key's owner, so that a resume can only link a file its
+// caller uploaded
+func (h *ResumeHandler) sourceUpload(c *gin.Context, request models.ParseResumeRequest) (*models.ResumeUpload, error) {
+	var upload *models.ResumeUpload
+	var err error
+	field := "uploadId"
+	switch {
+	case request.UploadID != 0:
+		upload, err = h.uploads.Get(c.Request.Context(), request.UploadID)
+	case request.FileName != "":
+		field = "fileName"
+		upload, err = h.uploads.GetByKey(c.Request.Context(), request.FileName)
+	default:
+		return nil, middleware.InvalidField("fileName", "or uploadId is required")
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, middleware.InvalidField(field, "must name a completed upload")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up upload: %w", err)
+	}
+
+	if upload.OwnerID != middleware.CurrentAPIKey(c).OwnerID {
+		return nil, middleware.NewProblem(http.StatusForbidden, middleware.CodeForbidden, "Upload belongs to another owner")
+	}
+	if upload.Status != models.UploadCompleted {
+		return nil, middleware.InvalidField(field, "must name a completed upload")
+	}
+	return upload, nil
+}
+
+// CompleteUpload godoc
+// @Summary Confirm a resume upload
+// @Description Confirm that the file of an upload has been sent. The stored file must have the declared size and content type; otherwise it is deleted and the upload rejected. Confirming an already completed upload returns it unchanged.
+// @Tags resume
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "API Key"
+// @Param id path int true "Upload ID"
+// @Success 200 {object} models.ResumeUpload
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Failure 409 {object} middleware.Problem
+// @Failure 422 {object} middleware.Problem
+// @Failure 503 {object} middleware.Problem
+// @Router /api/v1/resume/uploads/{id}/complete [post]
+func (h *ResumeHandler) CompleteUpload(c *gin.Context) {
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !h.storageAvailable(c) {
+		return
+	}
+
+	upload, err := h.uploads.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(lookupError(err, "Upload not found"))
+		return
+	}
+	if upload.OwnerID != middleware.CurrentAPIKey(c).OwnerID {
+		c.Error(middleware.NewProblem(http.StatusForbidden, middleware.CodeForbidden, "Upload belongs to another owner"))
+		return
+	}
+	switch upload.Status {
+	case models.UploadCompleted:
+		c.JSON(http.StatusOK, upload)
+		return
+	case models.UploadRejected:
+		c.Error(middleware.NewProblem(http.StatusUnprocessableEntity, middleware.CodeUploadRejected, "The upload was rejected; request a new upload URL"))
+		return
+	}
+
+	info, err := h.storage.Head(c.Request.Context(), upload.Key)
+	if errors.Is(err, services.ErrObjectNotFound) {
+		c.Error(middleware.NewProblem(http.StatusConflict, middleware.CodeUploadIncomplete, "No file has been uploaded yet"))
+		return
+	}
+	if err != nil {
+		c.Error(fmt.Errorf("failed to check upload: %w", err))
+		return
+	}
+	if detail := uploadMismatch(upload, info); detail != "" {
+		h.rejectUpload(c, upload, detail)
+		return
+	}
+
+	now := time.Now()
+	upload.Status = models.UploadCompleted
+	upload.CompletedAt = &now
+	if err := h.uploads.Update(c.Request.Context(), upload); err != nil {
+		c.Error(fmt.Errorf("failed to complete upload: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, upload)
+}
+
+// uploadMismatch describes how a stored file differs from what its upload
+// declared, or returns "" when it matches. Storage that does not enforce the
+// presigned policy itself is caught here.
+func uploadMismatch(upload *models.ResumeUpload, info *services.ObjectInfo) string {
+	if info.Size != upload.Size {
+		return fmt.Sprintf("The uploaded file has %d bytes instead of the declared %d", info.Size, upload.Size)
+	}
+	mediaType, _, err := mime.ParseMediaType(info.ContentType)
+	if err != nil || mediaType != upload.ContentType {
+		return fmt.Sprintf("The uploaded file has content type %q instead of %q", info.ContentType, upload.ContentType)
+	}
+	return ""
+}
+
+// rejectUpload deletes the file of an upload that broke its policy and
+// marks the upload as rejected
+func (h *ResumeHandler) rejectUpload(c *gin.Context, upload *models.ResumeUpload, detail string) {
+	if err := h.storage.Delete(c.Request.Context(), upload.Key); err != nil {
+		c.Error(fmt.Errorf("failed to delete rejected upload: %w", err))
+		return
+	}
+	upload.Status = models.UploadRejected
+	if err := h.uploads.Update(c.Request.Context(), upload); err != nil {
+		c.Error(fmt.Errorf("failed to reject upload: %w", err))
+		return
+	}
+	c.Error(middleware.NewProblem(http.StatusUnprocessableEntity, middleware.CodeUploadRejected, detail))
+}
+
+// GetResumeFile godoc
+// @Summary Get a download URL for a resume's file
+// @Description Get a short-lived presigned URL for downloading the original file a resume was parsed from
+// @Tags resume
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "API Key"
+// @Param id path int true "Resume ID"
+// @Success 200 {object} FileURLResponse
+// @Failure 400 {object} middleware.Problem
+// @Failure 401 {object} middleware.Problem
+// @Failure 403 {object} middleware.Problem
+// @Failure 404 {object} middleware.Problem
+// @Failure 503 {object} middleware.Problem
+// @Router /api/v1/resume/{id}/file [get]
+func (h *ResumeHandler) GetResumeFile(c *gin.Context) {
+	id, err := parseID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !h.storageAvailable(c) {
+		return
+	}
+
+	resume, err := h.resumes.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(lookupError(err, "Resume not found"))
+		return
+	}
+	if resume.SourceKey == "" {
+		c.Error(middleware.NewProblem(http.StatusNotFound, middleware.CodeNotFound, "Resume has no source file"))
+		return
+	}
+
+	info, err := h.storage.Head(c.Request.Context(), resume.SourceKey)
+	if errors.Is(err, services.ErrObjectNotFound) || errors.Is(err, services.ErrInvalidStorageKey) {
+		c.Error(middleware.NewProblem(http.StatusNotFound, middleware.CodeNotFound, "The source file of the resume no longer exists"))
+		return
+	}
+	if err != nil {
+		c.Error(fmt.Errorf("failed to check source file: %w", err))
+		return
+	}
+
+	url, err := h.storage.PresignGet(c.Request.Context(), info.Key, h.settings.DownloadURLExpiry)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, FileURLResponse{
+		URL:         url,
+		Key:         info.Key,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ExpiresAt:   time.Now().Add(h.settings.DownloadURLExpiry),
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-server/middleware"
+	"go-server/repository"
+	"go-server/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// respondUpstreamError records the problem for a failed call to an upstream
+// service. An open circuit breaker becomes 503 with a Retry-After header, an
+// error status from upstream 502 with that status attached, and anything
+// else 502 with detail; the underlying error is only logged.
+func respondUpstreamError(c *gin.Context, err error, detail string) {
+	var open *services.CircuitOpenError
+	var statusErr *services.ParserStatusError
+	var chatStatusErr *services.ChatStatusError
+	switch {
+	case errors.As(err, &open):
+		c.Header("Retry-After", services.RetryAfterSeconds(open))
+		c.Error(middleware.NewProblem(http.StatusServiceUnavailable, middleware.CodeUpstreamUnavailable,
+			"The "+open.Name+" service is temporarily unavailable").WithCause(err))
+	case errors.Is(err, services.ErrParserNotConfigured):
+		c.Error(middleware.NewProblem(http.StatusServiceUnavailable, middleware.CodeServiceUnavailable,
+			"Resume parsing is not configured").WithCause(err))
+	case errors.As(err, &statusErr):
+		problem := middleware.NewProblem(http.StatusBadGateway, middleware.CodeUpstreamError, detail).WithCause(err)
+		problem.UpstreamStatus = statusErr.StatusCode
+		c.Error(problem)
+	case errors.As(err, &chatStatusErr):
+		problem := middleware.NewProblem(http.StatusBadGateway, middleware.CodeUpstreamError, detail).WithCause(err)
+		problem.UpstreamStatus = chatStatusErr.StatusCode
+		c.Error(problem)
+	default:
+		c.Error(middleware.NewProblem(http.StatusBadGateway, middleware.CodeUpstreamError, detail).WithCause(err))
+	}
+}
+
+// parseID reads the numeric path parameter name
+func parseID(c *gin.Context, name string) (uint, error) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		return 0, middleware.InvalidField(name, "must be a positive integer")
+	}
+	return uint(id), nil
+}
+
+// lookupError turns a missing record into a not_found problem with detail;
+// any other error stays internal
+func lookupError(err error, detail string) error {
+	if errors.Is(err, repository.ErrNotFound) || errors.Is(err, gorm.ErrRecordNotFound) {
+		return middleware.NewProblem(http.StatusNotFound, middleware.CodeNotFound, detail)
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold is the duration above which queries are logged as slow
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger writes GORM's logs to slog. Queries are logged with
+// placeholders instead of their bound values, which may hold credentials
+// or resume text, and missing records are left to the caller to report.
+type gormLogger struct {
+	logger *slog.Logger
+	config gormlogger.Config
+}
+
+// NewGormLogger returns a GORM logger writing to logger. Every query is
+// logged at debug level, slow queries and failures at the levels above.
+func NewGormLogger(logger *slog.Logger, level slog.Level) gormlogger.Interface {
+	return &gormLogger{
+		logger: logger,
+		config: gormlogger.Config{
+			SlowThreshold:             slowQueryThreshold,
+			LogLevel:                  gormLevel(level),
+			IgnoreRecordNotFoundError: true,
+			ParameterizedQueries:      true,
+		},
+	}
+}
+
+// gormLevel maps a slog level onto the closest GORM level
+func gormLevel(level slog.Level) gormlogger.LogLevel {
+	switch {
+	case level <= slog.LevelDebug:
+		return gormlogger.Info
+	case level <= slog.LevelWarn:
+		return gormlogger.Warn
+	default:
+		return gormlogger.Error
+	}
+}
+
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *l
+	copied.config.LogLevel = level
+	return &copied
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= gormlogger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= gormlogger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= gormlogger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.config.LogLevel <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && l.config.LogLevel >= gormlogger.Error && !(l.config.IgnoreRecordNotFoundError && errors.Is(err, gormlogger.ErrRecordNotFound)):
+		l.logger.ErrorContext(ctx, "database query failed", append(queryAttrs(fc, elapsed), "error", err)...)
+	case l.config.SlowThreshold != 0 && elapsed > l.config.SlowThreshold && l.config.LogLevel >= gormlogger.Warn:
+		l.logger.WarnContext(ctx, "slow database query", queryAttrs(fc, elapsed)...)
+	case l.config.LogLevel >= gormlogger.Info:
+		l.logger.DebugContext(ctx, "database query", queryAttrs(fc, elapsed)...)
+	}
+}
+
+// ParamsFilter drops the bound values so that fc passed to Trace returns
+// the query with its placeholders
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.config.ParameterizedQueries {
+		return sql, nil
+	}
+	return sql, params
+}
+
+func queryAttrs(fc func() (string, int64), elapsed time.Duration) []interface{} {
+	sql, rows := fc()
+	return []interface{}{"sql", sql, "rows", rows, "elapsed_ms", elapsed.Milliseconds()}
+}
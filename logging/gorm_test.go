@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go-server/models"
+	"go-server/testdb"
+
+	"gorm.io/gorm"
+)
+
+func TestGormLoggerOmitsBoundValues(t *testing.T) {
+	var out bytes.Buffer
+	db := testdb.Open(t).Session(&gorm.Session{
+		Logger: NewGormLogger(slog.New(NewHandler(&out, slog.LevelDebug)), slog.LevelDebug),
+	})
+	ctx := context.Background()
+
+	err := db.WithContext(ctx).Exec("INSERT INTO missing_table (secret) VALUES (?)", "hunter2-secret").Error
+	if err == nil {
+		t.Fatal("insert into a missing table succeeded")
+	}
+	var resume models.Resume
+	if err := db.WithContext(ctx).Where("user_id = ?", "alice@example.com").First(&resume).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("lookup error = %v, want not found", err)
+	}
+
+	logged := out.String()
+	if !strings.Contains(logged, "database query failed") || !strings.Contains(logged, "missing_table") {
+		t.Errorf("failed query not logged: %s", logged)
+	}
+	if strings.Contains(logged, "hunter2-secret") || strings.Contains(logged, "alice@example.com") {
+		t.Errorf("log leaks bound values: %s", logged)
+	}
+	if strings.Count(logged, `"level":"ERROR"`) != 1 {
+		t.Errorf("want only the failed insert logged as an error: %s", logged)
+	}
+}
@@ -1,20 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"go-server/config"
 	"go-server/docs"
 	"go-server/handlers"
-	"go-server/middleware"
-	"go-server/models"
+	"go-server/logging"
+	"go-server/metrics"
+	"go-server/migrations"
+	"go-server/repository"
+	"go-server/services"
+	"go-server/tracing"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
 )
 
 // @title E-commerce API
@@ -24,82 +32,260 @@ import (
 // @BasePath /
 // @schemes http
 func main() {
-	// Load .env file
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error loading .env file")
+	// Load and validate configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	// Update Swagger host from environment
-	docs.SwaggerInfo.Host = config.GetSwaggerHost()
+	// Log structured JSON, tagged with request IDs and with secrets redacted
+	slog.SetDefault(slog.New(logging.NewHandler(os.Stdout, cfg.Log.SlogLevel())))
 
-	// Initialize database
-	db := config.ConnectDB()
+	// Export traces and propagate W3C trace context
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal("Failed to set up tracing:", err)
+	}
+
+	// Update Swagger host from configuration
+	docs.SwaggerInfo.Host = cfg.Swagger.Host
+
+	// Initialize database; queries are logged without their bound values
+	db := config.ConnectDB(cfg.Database, logging.NewGormLogger(slog.Default(), cfg.Log.SlogLevel()))
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		log.Fatal("Failed to instrument database:", err)
+	}
+
+	// Run a management command instead of the server if one was given
+	if len(os.Args) > 1 {
+		if err := runCommand(db, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	// Auto migrate the schema
-	db.AutoMigrate(&models.Product{}, &models.Resume{})
+	// Refuse to serve against an outdated schema
+	migrator, err := migrations.New(db)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		log.Fatalf("Database schema check failed: %v (run: bonga migrate up)", err)
+	}
 
-	// Initialize router
-	r := gin.Default()
+	// Refuse to serve without any way to authenticate
+	apiKeys := services.NewAPIKeyStore(db)
+	if count, err := apiKeys.Count(context.Background()); err != nil {
+		log.Fatal("Failed to count API keys:", err)
+	} else if count == 0 {
+		log.Fatal("No API keys configured; create one with: bonga apikey create -name <name> -owner <owner> -scopes resume:read,resume:write,session:chat")
+	}
 
-	// Configure CORS
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-	}))
+	// Expose connection pool statistics
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDB(sqlDB)
+	}
 
-	// Health check endpoint
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status": "BONGA! JODU!! BONGA!",
-		})
+	// Initialize upstream clients
+	if cfg.Parser.Token == "" {
+		log.Print("PARSE_API_TOKEN is not set; resume parsing will answer 503")
+	}
+	parser := services.NewHTTPResumeParser(cfg.Parser)
+	chat := services.NewChatClient(cfg.Chat)
+
+	// Initialize storage; the server still starts without it, but readiness
+	// reports the failure
+	storage, localStorage, storageErr := newStorage(cfg)
+	if storageErr != nil {
+		log.Printf("Resume storage (%s) unavailable: %v", cfg.Storage.Backend, storageErr)
+	}
+
+	// Initialize repositories, workers and handlers
+	productRepo := repository.NewGormProducts(db)
+	resumeRepo := repository.NewGormResumes(db)
+	sessionRepo := repository.NewGormSessions(db)
+	uploadRepo := repository.NewGormUploads(db)
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	parseQueue := services.NewParseQueue(db, resumeRepo, parser)
+	parseQueue.Start(workerCtx, cfg.Parser.Workers)
+
+	r := newRouter(routes{
+		serviceName: cfg.Tracing.ServiceName,
+		apiKeys:     apiKeys,
+		health:      handlers.NewHealthHandler(readinessChecks(db, storage, storageErr, parser, chat)...),
+		status: func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{
+				"status": "BONGA! JODU!! BONGA!",
+				"upstreams": []services.BreakerStatus{
+					parser.Breaker().Status(),
+					chat.Breaker().Status(),
+				},
+			})
+		},
+		products: &handlers.ProductHandler{Products: productRepo},
+		resumes: handlers.NewResumeHandler(resumeRepo, uploadRepo, storage, handlers.UploadSettings{
+			UploadURLExpiry:   cfg.Storage.UploadURLExpiry,
+			DownloadURLExpiry: cfg.Storage.DownloadURLExpiry,
+			MaxBytes:          int64(cfg.Storage.MaxUploadBytes),
+		}, parser, parseQueue),
+		sessions: handlers.NewSessionHandler(sessionRepo, resumeRepo, chat, cfg.Session.TTL),
+		storage:  storageHandler(localStorage),
 	})
 
-	// Initialize handlers
-	productHandler := &handlers.ProductHandler{DB: db}
-	resumeHandler := handlers.NewResumeHandler(db)
-	sessionHandler := handlers.NewSessionHandler(db)
+	// Start server
+	serverConfig := cfg.Server
+	srv := &http.Server{
+		Addr:              serverConfig.Addr,
+		Handler:           r,
+		ReadTimeout:       serverConfig.ReadTimeout,
+		ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+		WriteTimeout:      serverConfig.WriteTimeout,
+		IdleTimeout:       serverConfig.IdleTimeout,
+		MaxHeaderBytes:    serverConfig.MaxHeaderBytes,
+	}
 
-	// Product routes
-	v1 := r.Group("/api/v1")
-	{
-		products := v1.Group("/products")
-		{
-			products.GET("", productHandler.GetProducts)
-			products.GET("/:id", productHandler.GetProduct)
-			products.POST("", productHandler.CreateProduct)
-			products.PUT("/:id", productHandler.UpdateProduct)
-			products.DELETE("/:id", productHandler.DeleteProduct)
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Listening on %s (TLS: %t)", srv.Addr, serverConfig.TLSEnabled())
+		var err error
+		if serverConfig.TLSEnabled() {
+			err = srv.ListenAndServeTLS(serverConfig.TLSCertFile, serverConfig.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
 		}
+	}()
 
-		// Resume routes with API key authentication
-		resumes := v1.Group("/resume")
-		resumes.Use(middleware.APIKeyAuth())
-		{
-			resumes.GET("/latest", resumeHandler.LatestResume)
-			resumes.GET("/getSignedUrl", resumeHandler.GetSignedURL)
-			resumes.POST("", resumeHandler.CreateResume)
-			resumes.GET("/:id", resumeHandler.GetResume)
-			resumes.PUT("/:id", resumeHandler.UpdateResume)
-			resumes.DELETE("/:id", resumeHandler.DeleteResume)
+	stop, cancelSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancelSignals()
+
+	select {
+	case err := <-serverErr:
+		log.Fatal("Failed to start server:", err)
+	case <-stop.Done():
+	}
+
+	shutdown(srv, db, parseQueue, stopWorkers, shutdownTracing, serverConfig.ShutdownTimeout)
+}
+
+// newStorage creates the configured storage backend. The local backend is
+// also returned on its own since this server answers its signed URLs.
+func newStorage(cfg *config.Config) (services.Storage, *services.LocalStorage, error) {
+	if cfg.Storage.Backend == "local" {
+		local, err := services.NewLocalStorage(cfg.Storage.Dir, cfg.Storage.PublicURL, cfg.Storage.Secret)
+		if err != nil {
+			return nil, nil, err
 		}
+		if cfg.Storage.Secret == "" {
+			log.Print("STORAGE_SECRET is not set; signed storage URLs will not survive a restart")
+		}
+		return local, local, nil
+	}
+
+	s3Service, err := services.NewS3Service(services.S3Config{
+		Bucket:       cfg.S3.Bucket,
+		Region:       cfg.S3.Region,
+		Endpoint:     cfg.S3.Endpoint,
+		UsePathStyle: cfg.S3.UsePathStyle,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return s3Service, nil, nil
+}
+
+// storageHandler serves the URLs signed by the local backend, if it is used
+func storageHandler(local *services.LocalStorage) *handlers.StorageHandler {
+	if local == nil {
+		return nil
+	}
+	return handlers.NewStorageHandler(local)
+}
 
-		// Session routes with API key authentication
-		sessions := v1.Group("/session")
-		sessions.Use(middleware.APIKeyAuth())
+// readinessChecks builds the dependency checks reported by /readyz. The
+// database and storage are critical; the upstream services only degrade the
+// pod since every replica shares them.
+func readinessChecks(db *gorm.DB, storage services.Storage, storageErr error, parser *services.HTTPResumeParser, chat *services.ChatClient) []handlers.HealthCheck {
+	return []handlers.HealthCheck{
 		{
-			sessions.GET("/init", sessionHandler.InitSession)
-			sessions.POST("/chat", sessionHandler.ChatSession)
-		}
+			Name:     "database",
+			Critical: true,
+			Check: func(ctx context.Context) (interface{}, error) {
+				sqlDB, err := db.DB()
+				if err != nil {
+					return nil, err
+				}
+				stats := sqlDB.Stats()
+				details := gin.H{
+					"open_connections": stats.OpenConnections,
+					"in_use":           stats.InUse,
+					"idle":             stats.Idle,
+					"wait_count":       stats.WaitCount,
+					"wait_duration_ms": stats.WaitDuration.Milliseconds(),
+				}
+				return details, sqlDB.PingContext(ctx)
+			},
+		},
+		{
+			Name:     "storage",
+			Critical: true,
+			Check: func(ctx context.Context) (interface{}, error) {
+				if storage == nil {
+					return nil, storageErr
+				}
+				return nil, storage.Ping(ctx)
+			},
+		},
+		{
+			Name: "parser",
+			Check: func(ctx context.Context) (interface{}, error) {
+				return parser.Breaker().Status(), parser.Ping(ctx)
+			},
+		},
+		{
+			Name: "chat",
+			Check: func(ctx context.Context) (interface{}, error) {
+				return chat.Breaker().Status(), chat.Ping(ctx)
+			},
+		},
 	}
+}
 
-	// Swagger documentation
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+// shutdown drains in-flight requests, stops the background workers, closes
+// the database pool and flushes pending spans, giving up once timeout has
+// elapsed
+func shutdown(srv *http.Server, db *gorm.DB, parseQueue *services.ParseQueue, stopWorkers context.CancelFunc, shutdownTracing func(context.Context) error, timeout time.Duration) {
+	log.Printf("Shutting down, waiting up to %s for in-flight work", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	// Start server
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Failed to start server:", err)
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("HTTP server did not drain cleanly: %v", err)
+	}
+
+	stopWorkers()
+	workersDone := make(chan struct{})
+	go func() {
+		parseQueue.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+		log.Print("Background workers did not stop in time")
+	}
+
+	if sqlDB, err := db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			log.Printf("Failed to close database pool: %v", err)
+		}
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
 	}
-} 
\ No newline at end of file
+	log.Print("Shutdown complete")
+}
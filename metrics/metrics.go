@@ -0,0 +1,107 @@
+// Package metrics defines the Prometheus metrics exposed on /metrics
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "bonga"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by route template and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency, by route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Latency of outbound calls to the parser, chat and S3 services.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 20, 40, 60},
+	}, []string{"upstream", "outcome"})
+
+	upstreamErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_errors_total",
+		Help:      "Failed outbound calls to the parser, chat and S3 services.",
+	}, []string{"upstream"})
+
+	// ResumesCreated counts resumes stored, synchronously or by a parse job
+	ResumesCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "resumes_created_total",
+		Help:      "Resumes created.",
+	})
+
+	// SessionsStarted counts initialized chat sessions
+	SessionsStarted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sessions_started_total",
+		Help:      "Chat sessions started.",
+	})
+
+	// ChatTurns counts answered questions, by "sync" or "stream" mode
+	ChatTurns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chat_turns_total",
+		Help:      "Chat questions answered.",
+	}, []string{"mode"})
+)
+
+// Middleware records request counts, latency and in-flight requests. Routes
+// are labelled by their template (e.g. /api/v1/resume/:id) to keep the
+// label cardinality bounded.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		httpInFlight.Inc()
+		started := time.Now()
+
+		c.Next()
+
+		httpInFlight.Dec()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(started).Seconds())
+	}
+}
+
+// ObserveUpstream records the latency and outcome of an outbound call that
+// started at started
+func ObserveUpstream(upstream string, started time.Time, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+		upstreamErrors.WithLabelValues(upstream).Inc()
+	}
+	upstreamDuration.WithLabelValues(upstream, outcome).Observe(time.Since(started).Seconds())
+}
+
+// RegisterDB exposes the connection pool statistics of db
+func RegisterDB(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
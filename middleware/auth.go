@@ -1,26 +1,65 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
-	"os"
+	"strings"
+
+	"go-server/models"
+	"go-server/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-func APIKeyAuth() gin.HandlerFunc {
+// apiKeyContextKey is the gin context key holding the authenticated key
+const apiKeyContextKey = "apiKey"
+
+// APIKeyAuthenticator verifies a plain API key
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, plain string) (*models.APIKey, error)
+}
+
+// APIKeyAuth authenticates the Authorization header (optionally prefixed
+// with "Bearer ") against the key store and requires the key to grant scope
+func APIKeyAuth(keys APIKeyAuthenticator, scope string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		apiKey := c.GetHeader("Authorization")
-		expectedAPIKey := os.Getenv("API_KEY")
-		if expectedAPIKey == "" {
-			expectedAPIKey = "BONGA_SERVER"
+		plain := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if plain == "" {
+			c.Error(NewProblem(http.StatusUnauthorized, CodeUnauthorized, "Invalid or missing API key"))
+			c.Abort()
+			return
+		}
+
+		key, err := keys.Authenticate(c.Request.Context(), plain)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIKey) {
+				c.Error(NewProblem(http.StatusUnauthorized, CodeUnauthorized, "Invalid or missing API key"))
+			} else {
+				c.Error(fmt.Errorf("failed to verify API key: %w", err))
+			}
+			c.Abort()
+			return
 		}
 
-		if apiKey == "" || apiKey != expectedAPIKey {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing API key"})
+		if !key.HasScope(scope) {
+			c.Error(NewProblem(http.StatusForbidden, CodeForbidden, "API key lacks the "+scope+" scope"))
 			c.Abort()
 			return
 		}
 
+		c.Set(apiKeyContextKey, key)
 		c.Next()
 	}
-} 
\ No newline at end of file
+}
+
+// CurrentAPIKey returns the key authenticated by APIKeyAuth, if any
+func CurrentAPIKey(c *gin.Context) *models.APIKey {
+	if v, ok := c.Get(apiKeyContextKey); ok {
+		if key, ok := v.(*models.APIKey); ok {
+			return key
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit rejects request bodies larger than n bytes with a
+// request_too_large problem (413). Bodies without a declared length are cut
+// off at n bytes, which BindingProblem reports the same way.
+func BodyLimit(n int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > n {
+			c.Error(bodyTooLarge(n))
+			c.Abort()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, n)
+		c.Next()
+	}
+}
+
+func bodyTooLarge(n int64) *Problem {
+	return NewProblem(http.StatusRequestEntityTooLarge, CodeRequestTooLarge, fmt.Sprintf("The request body exceeds %d bytes", n))
+}
@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"go-server/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ProblemContentType is the media type of every error response
+const ProblemContentType = "application/problem+json"
+
+// Stable problem codes. Clients should branch on these rather than on the
+// human readable title or detail, which may change.
+const (
+	CodeValidationFailed    = "validation_failed"
+	CodeMalformedRequest    = "malformed_request"
+	CodeRequestTooLarge     = "request_too_large"
+	CodeUnauthorized        = "unauthorized"
+	CodeForbidden           = "forbidden"
+	CodeNotFound            = "not_found"
+	CodeSessionExpired      = "session_expired"
+	CodeUploadIncomplete    = "upload_incomplete"
+	CodeUploadRejected      = "upload_rejected"
+	CodeUpstreamError       = "upstream_error"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+	CodeServiceUnavailable  = "service_unavailable"
+	CodeInternalError       = "internal_error"
+)
+
+// Problem is an RFC 7807 problem details object. It doubles as an error, so
+// handlers report failures with c.Error(problem) and the Problems
+// middleware renders them.
+type Problem struct {
+	Type      string       `json:"type" example:"/problems/not_found"`
+	Title     string       `json:"title" example:"Not Found"`
+	Status    int          `json:"status" example:"404"`
+	Detail    string       `json:"detail,omitempty" example:"Resume not found"`
+	Instance  string       `json:"instance,omitempty" example:"/api/v1/resume/42"`
+	Code      string       `json:"code" example:"not_found"`
+	RequestID string       `json:"request_id,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// UpstreamStatus is the status an upstream service answered with
+	UpstreamStatus int `json:"upstream_status,omitempty" example:"500"`
+
+	// cause is logged with the request but never sent to the client
+	cause error
+}
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field" example:"fileName"`
+	Code    string `json:"code" example:"required"`
+	Message string `json:"message" example:"is required"`
+}
+
+// NewProblem creates a problem with the given status, stable code and
+// client-facing detail
+func NewProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "/problems/" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.cause != nil {
+		return p.Code + ": " + p.Detail + ": " + p.cause.Error()
+	}
+	return p.Code + ": " + p.Detail
+}
+
+func (p *Problem) Unwrap() error {
+	return p.cause
+}
+
+// WithCause attaches the underlying error, which ends up in the request
+// log line but not in the response
+func (p *Problem) WithCause(err error) *Problem {
+	p.cause = err
+	return p
+}
+
+// InvalidField reports a single invalid request field, such as a query
+// parameter that does not parse
+func InvalidField(field, message string) *Problem {
+	p := NewProblem(http.StatusBadRequest, CodeValidationFailed, field+" "+message)
+	p.Errors = []FieldError{{Field: field, Code: "invalid", Message: message}}
+	return p
+}
+
+// BindingProblem turns the error of c.ShouldBindJSON into a problem that
+// lists every rejected field
+func BindingProblem(err error) *Problem {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		p := NewProblem(http.StatusBadRequest, CodeValidationFailed, "The request body has invalid fields")
+		for _, fe := range validationErrs {
+			p.Errors = append(p.Errors, FieldError{
+				Field:   fe.Field(),
+				Code:    fe.Tag(),
+				Message: validationMessage(fe),
+			})
+		}
+		return p
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return bodyTooLarge(tooLarge.Limit)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		p := NewProblem(http.StatusBadRequest, CodeValidationFailed, "The request body has invalid fields")
+		p.Errors = []FieldError{{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: "must be of type " + typeErr.Type.String(),
+		}}
+		return p
+	}
+
+	if errors.Is(err, io.EOF) {
+		return NewProblem(http.StatusBadRequest, CodeMalformedRequest, "The request body is empty")
+	}
+	return NewProblem(http.StatusBadRequest, CodeMalformedRequest, "The request body is not valid JSON")
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of " + fe.Param()
+	}
+	return fmt.Sprintf("failed the %s check", fe.Tag())
+}
+
+func init() {
+	// Report fields by their JSON names rather than the Go struct names
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// ProblemFor returns the problem reported to the client for err. Anything
+// that is not a *Problem is an internal error whose message stays in the
+// logs.
+func ProblemFor(c *gin.Context, err error) *Problem {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		copied := *problem
+		problem = &copied
+	} else {
+		problem = NewProblem(http.StatusInternalServerError, CodeInternalError, "An unexpected error occurred")
+	}
+	problem.Instance = c.Request.URL.Path
+	problem.RequestID = logging.RequestID(c.Request.Context())
+	return problem
+}
+
+// WriteProblem writes err as an application/problem+json response
+func WriteProblem(c *gin.Context, err error) {
+	problem := ProblemFor(c, err)
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(problem.Status, problem)
+}
+
+// Problems renders the last error a handler recorded with c.Error, unless
+// the handler already wrote a response
+func Problems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		WriteProblem(c, c.Errors.Last().Err)
+	}
+}
+
+// Recovery turns panics into internal_error problems
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		c.Error(fmt.Errorf("panic: %v", recovered))
+		WriteProblem(c, c.Errors.Last().Err)
+		c.Abort()
+	})
+}
+
+// NoRoute answers requests for unknown routes with a not_found problem
+func NoRoute(c *gin.Context) {
+	c.Error(NewProblem(http.StatusNotFound, CodeNotFound, "No route matches "+c.Request.Method+" "+c.Request.URL.Path))
+}
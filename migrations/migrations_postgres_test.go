@@ -0,0 +1,130 @@
+//go:build postgres
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go-server/testdb"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openPostgres connects to the database named by TEST_DATABASE_URL and
+// confines the test to a fresh schema that is dropped afterwards
+func openPostgres(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A single connection keeps the search path for the whole test
+	sqlDB.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("migrations_test_%d", time.Now().UnixNano())
+	if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		sqlDB.Close()
+	})
+	if err := db.Exec("SET search_path TO " + schema).Error; err != nil {
+		t.Fatalf("set search path: %v", err)
+	}
+	return db
+}
+
+func TestMigrationsMatchModels(t *testing.T) {
+	db := openPostgres(t)
+	ctx := context.Background()
+	migrator, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	checkSchema(t, db)
+
+	// Every down migration reverts its up migration cleanly
+	if _, err := migrator.Down(ctx, len(migrator.migrations)); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	for _, model := range testdb.Models() {
+		if db.Migrator().HasTable(model) {
+			t.Errorf("table of %T left behind after down", model)
+		}
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("up after down: %v", err)
+	}
+	if err := migrator.Check(ctx); err != nil {
+		t.Errorf("check: %v", err)
+	}
+}
+
+// checkSchema verifies that the migrated schema has every table, column and
+// index the models declare, and no required column the models cannot fill
+func checkSchema(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	for _, model := range testdb.Models() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatalf("parse %T: %v", model, err)
+		}
+		table := stmt.Schema.Table
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s of %T is missing", table, model)
+			continue
+		}
+
+		known := map[string]bool{}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			known[field.DBName] = true
+			if !db.Migrator().HasColumn(model, field.DBName) {
+				t.Errorf("column %s.%s is missing", table, field.DBName)
+			}
+		}
+		for _, index := range stmt.Schema.ParseIndexes() {
+			if !db.Migrator().HasIndex(model, index.Name) {
+				t.Errorf("index %s on %s is missing", index.Name, table)
+			}
+		}
+
+		columns, err := db.Migrator().ColumnTypes(model)
+		if err != nil {
+			t.Fatalf("columns of %s: %v", table, err)
+		}
+		for _, column := range columns {
+			if known[column.Name()] {
+				continue
+			}
+			nullable, _ := column.Nullable()
+			_, hasDefault := column.DefaultValue()
+			if !nullable && !hasDefault {
+				t.Errorf("column %s.%s is required but not in the model", table, column.Name())
+			}
+		}
+	}
+}
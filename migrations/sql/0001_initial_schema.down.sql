@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS parse_jobs;
+DROP TABLE IF EXISTS chat_messages;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS resumes;
+DROP TABLE IF EXISTS products;
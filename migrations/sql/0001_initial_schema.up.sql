@@ -0,0 +1,74 @@
+-- Baseline of the schema previously created by GORM's AutoMigrate. Every
+-- statement is idempotent so that existing databases can adopt migrations
+-- without changes.
+
+CREATE TABLE IF NOT EXISTS products (
+    id          BIGSERIAL PRIMARY KEY,
+    seller_id   BIGINT,
+    title       TEXT,
+    description TEXT,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS resumes (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    raw_text   TEXT NOT NULL,
+    metadata   JSONB,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id             VARCHAR(64) PRIMARY KEY,
+    owner_id       TEXT NOT NULL,
+    resume_id      BIGINT,
+    status         TEXT NOT NULL DEFAULT 'active',
+    created_at     TIMESTAMPTZ NOT NULL,
+    last_active_at TIMESTAMPTZ NOT NULL,
+    expires_at     TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_owner_id ON sessions (owner_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions (expires_at);
+
+CREATE TABLE IF NOT EXISTS chat_messages (
+    id              BIGSERIAL PRIMARY KEY,
+    session_id      VARCHAR(64) NOT NULL,
+    role            VARCHAR(16) NOT NULL,
+    content         TEXT NOT NULL,
+    upstream_status BIGINT,
+    latency_ms      BIGINT,
+    created_at      TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_chat_messages_session_id ON chat_messages (session_id);
+
+CREATE TABLE IF NOT EXISTS parse_jobs (
+    id          BIGSERIAL PRIMARY KEY,
+    file_name   TEXT NOT NULL,
+    status      VARCHAR(16) NOT NULL,
+    attempts    BIGINT NOT NULL DEFAULT 0,
+    resume_id   BIGINT,
+    error       TEXT,
+    started_at  TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_parse_jobs_status ON parse_jobs (status);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           BIGSERIAL PRIMARY KEY,
+    name         TEXT NOT NULL,
+    owner_id     TEXT NOT NULL,
+    prefix       VARCHAR(16) NOT NULL,
+    key_hash     VARCHAR(64) NOT NULL,
+    scopes       TEXT NOT NULL,
+    role         VARCHAR(16) NOT NULL DEFAULT 'user',
+    seller_id    BIGINT,
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
+CREATE INDEX IF NOT EXISTS idx_api_keys_owner_id ON api_keys (owner_id);
@@ -0,0 +1,3 @@
+ALTER TABLE resumes DROP COLUMN IF EXISTS source_key;
+
+DROP TABLE IF EXISTS resume_uploads;
@@ -0,0 +1,21 @@
+-- Upload intents recorded when presigned upload URLs are issued, and the
+-- storage key of the file each resume was parsed from.
+
+CREATE TABLE resume_uploads (
+    id           BIGSERIAL PRIMARY KEY,
+    key          TEXT NOT NULL,
+    file_name    TEXT NOT NULL,
+    owner_id     TEXT NOT NULL,
+    api_key_id   BIGINT,
+    status       VARCHAR(16) NOT NULL DEFAULT 'pending',
+    size         BIGINT,
+    content_type TEXT,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_resume_uploads_key ON resume_uploads (key);
+CREATE INDEX idx_resume_uploads_owner_id ON resume_uploads (owner_id);
+
+ALTER TABLE resumes ADD COLUMN source_key TEXT;
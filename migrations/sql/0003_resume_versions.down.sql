@@ -0,0 +1,4 @@
+ALTER TABLE parse_jobs DROP COLUMN IF EXISTS api_key_id;
+ALTER TABLE parse_jobs DROP COLUMN IF EXISTS owner_id;
+
+DROP TABLE IF EXISTS resume_versions;
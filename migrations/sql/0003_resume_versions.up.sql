@@ -0,0 +1,24 @@
+-- Snapshots of a resume's text and metadata taken on every change, and the
+-- API key that queued each parse job, which becomes the author of the
+-- resume it creates.
+
+CREATE TABLE resume_versions (
+    id            BIGSERIAL PRIMARY KEY,
+    resume_id     BIGINT NOT NULL REFERENCES resumes (id) ON DELETE CASCADE,
+    version       BIGINT NOT NULL,
+    raw_text      TEXT NOT NULL,
+    metadata      JSONB,
+    action        VARCHAR(16) NOT NULL,
+    api_key_id    BIGINT,
+    changed_by    TEXT NOT NULL DEFAULT '',
+    restored_from BIGINT,
+    created_at    TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX idx_resume_versions_resume_version ON resume_versions (resume_id, version);
+
+-- Existing resumes start their history with their current content
+INSERT INTO resume_versions (resume_id, version, raw_text, metadata, action, created_at)
+SELECT id, 1, raw_text, metadata, 'created', updated_at FROM resumes;
+
+ALTER TABLE parse_jobs ADD COLUMN owner_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE parse_jobs ADD COLUMN api_key_id BIGINT;
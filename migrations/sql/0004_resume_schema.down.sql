@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_resumes_schema_status;
+ALTER TABLE resumes DROP COLUMN IF EXISTS schema_errors;
+ALTER TABLE resumes DROP COLUMN IF EXISTS schema_status;
+ALTER TABLE resumes DROP COLUMN IF EXISTS schema_version;
+ALTER TABLE resumes DROP COLUMN IF EXISTS profile;
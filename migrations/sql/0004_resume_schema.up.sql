@@ -0,0 +1,9 @@
+-- The resume metadata normalized into the typed schema, and whether it
+-- fit. Existing resumes start at schema version 0; `resume normalize`
+-- fills these columns in for them.
+
+ALTER TABLE resumes ADD COLUMN profile JSONB;
+ALTER TABLE resumes ADD COLUMN schema_version BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE resumes ADD COLUMN schema_status VARCHAR(16);
+ALTER TABLE resumes ADD COLUMN schema_errors JSONB;
+CREATE INDEX idx_resumes_schema_status ON resumes (schema_status);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_resumes_owner_id;
+ALTER TABLE resumes DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,15 @@
+-- The owner of the API key that created a resume. Existing resumes take
+-- the author of their first version, or else the owner of the upload they
+-- were parsed from; resumes matching neither keep an empty owner, which
+-- only admin keys can attach to chat sessions.
+
+ALTER TABLE resumes ADD COLUMN owner_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX idx_resumes_owner_id ON resumes (owner_id);
+
+UPDATE resumes SET owner_id = v.changed_by
+FROM resume_versions v
+WHERE v.resume_id = resumes.id AND v.version = 1 AND v.changed_by <> '';
+
+UPDATE resumes SET owner_id = u.owner_id
+FROM resume_uploads u
+WHERE resumes.owner_id = '' AND u.key = resumes.source_key AND u.status = 'completed';
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// API key scopes
+const (
+	ScopeResumeRead   = "resume:read"
+	ScopeResumeWrite  = "resume:write"
+	ScopeSessionChat  = "session:chat"
+	ScopeProductWrite = "product:write"
+)
+
+// Scopes lists every scope the API enforces
+var Scopes = []string{ScopeResumeRead, ScopeResumeWrite, ScopeSessionChat, ScopeProductWrite}
+
+// ValidScope reports whether scope is one the API enforces
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// API key roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// APIKey is a hashed credential for calling the API. The plain key is only
+// shown once, when the key is created.
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey" example:"1"`
+	Name       string     `json:"name" gorm:"not null" example:"recruiter-dashboard"`
+	OwnerID    string     `json:"owner_id" gorm:"not null;index" example:"team-recruiting"`
+	Prefix     string     `json:"prefix" gorm:"size:16;not null;uniqueIndex" example:"3f9a1c2b"`
+	KeyHash    string     `json:"-" gorm:"size:64;not null"`
+	Scopes     string     `json:"scopes" gorm:"not null" example:"resume:read resume:write"`
+	Role       string     `json:"role" gorm:"size:16;not null;default:user" example:"user"`
+	SellerID   *uint      `json:"seller_id,omitempty" example:"1"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"not null"`
+}
+
+// HasScope reports whether the key grants scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range strings.Fields(k.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin reports whether the key may act on behalf of any seller
+func (k *APIKey) IsAdmin() bool {
+	return k.Role == RoleAdmin
+}
+
+// Expired reports whether the key can no longer be used at t
+func (k *APIKey) Expired(t time.Time) bool {
+	return k.ExpiresAt != nil && !t.Before(*k.ExpiresAt)
+}
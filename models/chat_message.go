@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// Chat message roles
+const (
+	ChatRoleUser      = "user"
+	ChatRoleAssistant = "assistant"
+)
+
+// ChatMessage is a single turn of a chat session
+// @Description Chat message information
+type ChatMessage struct {
+	ID             uint      `json:"id" gorm:"primaryKey" example:"1"`
+	SessionID      string    `json:"session_id" gorm:"size:64;not null;index" example:"session-4f1c2a9e0b7d4c3e8a6f5b2d1e0c9a8b"`
+	Role           string    `json:"role" gorm:"size:16;not null" example:"user"`
+	Content        string    `json:"content" gorm:"type:text;not null" example:"What is the candidate's most recent position?"`
+	UpstreamStatus int       `json:"upstream_status" example:"200"`
+	LatencyMs      int64     `json:"latency_ms" example:"850"`
+	CreatedAt      time.Time `json:"created_at" gorm:"not null"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// Parse job statuses
+const (
+	ParseJobPending   = "pending"
+	ParseJobRunning   = "running"
+	ParseJobSucceeded = "succeeded"
+	ParseJobFailed    = "failed"
+)
+
+// ParseJob is a queued request to parse a resume file in the background
+// @Description Resume parse job information
+type ParseJob struct {
+	ID       uint   `json:"id" gorm:"primaryKey" example:"1"`
+	FileName string `json:"file_name" gorm:"not null" example:"resumes/cv.pdf"`
+	// OwnerID and APIKeyID identify the API key that queued the job
+	OwnerID  string `json:"owner_id,omitempty" gorm:"not null;default:''" example:"team-recruiting"`
+	APIKeyID *uint  `json:"api_key_id,omitempty" example:"1"`
+	Status   string `json:"status" gorm:"size:16;not null;index" example:"pending"`
+	Attempts int    `json:"attempts" gorm:"not null;default:0" example:"0"`
+	ResumeID *uint  `json:"resume_id,omitempty" example:"1"`
+	// Error says why the job failed, without internal details
+	Error      string     `json:"error,omitempty" gorm:"type:text" example:"The resume could not be parsed"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"not null"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"not null"`
+}
@@ -7,12 +7,34 @@ import (
 )
 
 type Resume struct {
-	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
-	UserID    string    `json:"user_id" gorm:"not null"`
-	RawText   string    `json:"raw_text" gorm:"type:text;not null"`
-	Metadata  JSONB     `json:"metadata" gorm:"type:jsonb"`
-	CreatedAt time.Time `json:"created_at" gorm:"not null"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+	ID     uint   `json:"id" gorm:"primaryKey" example:"1"`
+	UserID string `json:"user_id" gorm:"not null"`
+	// OwnerID is the owner of the API key that created the resume
+	OwnerID   string `json:"owner_id,omitempty" gorm:"not null;default:'';index" example:"team-recruiting"`
+	RawText   string `json:"raw_text" gorm:"type:text;not null"`
+	Metadata  JSONB  `json:"metadata" gorm:"type:jsonb"`
+	SourceKey string `json:"source_key,omitempty" example:"resumes/cv.pdf"`
+	// Profile is Metadata normalized into the typed resume schema. It is
+	// derived on every save; SchemaStatus flags metadata that does not fit
+	// the schema and SchemaErrors says why.
+	Profile       *ResumeProfile `json:"profile,omitempty" gorm:"type:jsonb"`
+	SchemaVersion int            `json:"-" gorm:"not null;default:0"`
+	SchemaStatus  string         `json:"schema_status,omitempty" gorm:"size:16;index" example:"valid"`
+	SchemaErrors  SchemaErrors   `json:"schema_errors,omitempty" gorm:"type:jsonb"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"not null"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"not null"`
+}
+
+// ResumeSummary is the lightweight projection of a resume used in listings.
+// RawText is only populated when explicitly requested.
+type ResumeSummary struct {
+	ID           uint      `json:"id" example:"1"`
+	UserID       string    `json:"user_id"`
+	RawText      string    `json:"raw_text,omitempty"`
+	Metadata     JSONB     `json:"metadata"`
+	SchemaStatus string    `json:"schema_status,omitempty" example:"valid"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 type CreateResumeRequest struct {
@@ -20,12 +42,27 @@ type CreateResumeRequest struct {
 	Metadata JSONB  `json:"metadata"`
 }
 
+// UpdateResumeRequest replaces a resume's text and metadata
+type UpdateResumeRequest struct {
+	RawText  string `json:"raw_text" binding:"required"`
+	Metadata JSONB  `json:"metadata"`
+}
+
+// ParseResumeRequest names the file to parse: a completed upload of the API
+// key's owner, given by its ID or its storage key
 type ParseResumeRequest struct {
-	FileName string `json:"fileName" binding:"required"`
+	FileName string `json:"fileName" example:"resumes/team-recruiting/3f9a1c2b.pdf"`
+	UploadID uint   `json:"uploadId" example:"1"`
 }
 
 type JSONB map[string]interface{}
 
+// GormDataType tells GORM the column type, including in projections such
+// as ResumeSummary that carry no type tag
+func (JSONB) GormDataType() string {
+	return "jsonb"
+}
+
 // Value implements the driver.Valuer interface
 func (j JSONB) Value() (driver.Value, error) {
 	if j == nil {
@@ -40,11 +77,17 @@ func (j *JSONB) Scan(value interface{}) error {
 		*j = nil
 		return nil
 	}
-	
-	bytes, ok := value.([]byte)
-	if !ok {
+
+	// PostgreSQL returns jsonb as bytes, SQLite as a string
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
 		return nil
 	}
-	
+
 	return json.Unmarshal(bytes, j)
-} 
\ No newline at end of file
+}
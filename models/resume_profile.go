@@ -0,0 +1,125 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ResumeSchemaVersion is the version of the ResumeProfile layout. Bump it
+// whenever the layout or its normalization changes; `resume normalize`
+// then brings stored resumes up to date.
+const ResumeSchemaVersion = 1
+
+// Resume schema statuses
+const (
+	SchemaValid   = "valid"
+	SchemaInvalid = "invalid"
+)
+
+// ResumeProfile is a resume's metadata normalized into a typed schema.
+// Dates are YYYY-MM, or YYYY when the month is unknown.
+// @Description Typed resume schema
+type ResumeProfile struct {
+	SchemaVersion  int             `json:"schema_version" example:"1"`
+	Contact        Contact         `json:"contact"`
+	Summary        string          `json:"summary,omitempty" example:"Backend engineer focused on Go and PostgreSQL"`
+	Experience     []Experience    `json:"experience"`
+	Education      []Education     `json:"education"`
+	Skills         []string        `json:"skills" example:"go,sql"`
+	Certifications []Certification `json:"certifications"`
+	Languages      []Language      `json:"languages"`
+}
+
+// Contact holds how to reach the candidate
+type Contact struct {
+	Name     string   `json:"name" example:"Alice Smith"`
+	Email    string   `json:"email,omitempty" example:"alice@example.com"`
+	Phone    string   `json:"phone,omitempty" example:"+1 555 0100"`
+	Location string   `json:"location,omitempty" example:"Berlin, Germany"`
+	Links    []string `json:"links,omitempty" example:"https://github.com/alice"`
+}
+
+// Experience is one position held
+type Experience struct {
+	Title       string   `json:"title,omitempty" example:"Software Engineer"`
+	Company     string   `json:"company,omitempty" example:"Acme"`
+	Location    string   `json:"location,omitempty" example:"Remote"`
+	StartDate   string   `json:"start_date,omitempty" example:"2020-01"`
+	EndDate     string   `json:"end_date,omitempty" example:"2023-06"`
+	Current     bool     `json:"current,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Highlights  []string `json:"highlights,omitempty"`
+}
+
+// Education is one degree or course of study
+type Education struct {
+	Institution string `json:"institution" example:"TU Berlin"`
+	Degree      string `json:"degree,omitempty" example:"MSc"`
+	Field       string `json:"field,omitempty" example:"Computer Science"`
+	StartDate   string `json:"start_date,omitempty" example:"2016"`
+	EndDate     string `json:"end_date,omitempty" example:"2018"`
+	Grade       string `json:"grade,omitempty" example:"1.3"`
+}
+
+// Certification is a certificate or license
+type Certification struct {
+	Name   string `json:"name" example:"AWS Certified Developer"`
+	Issuer string `json:"issuer,omitempty" example:"Amazon Web Services"`
+	Date   string `json:"date,omitempty" example:"2022-03"`
+}
+
+// Language is a spoken language and how well it is spoken
+type Language struct {
+	Name        string `json:"name" example:"German"`
+	Proficiency string `json:"proficiency,omitempty" example:"fluent"`
+}
+
+// SchemaError is one reason a resume's metadata does not fit the schema
+type SchemaError struct {
+	Field   string `json:"field" example:"experience[0].start_date"`
+	Message string `json:"message" example:"is not a recognized date"`
+}
+
+// SchemaErrors is stored as a JSON array
+type SchemaErrors []SchemaError
+
+// Value implements the driver.Valuer interface
+func (p ResumeProfile) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+// Scan implements the sql.Scanner interface
+func (p *ResumeProfile) Scan(value interface{}) error {
+	return scanJSON(value, p)
+}
+
+// Value implements the driver.Valuer interface
+func (e SchemaErrors) Value() (driver.Value, error) {
+	if e == nil {
+		return nil, nil
+	}
+	return json.Marshal(e)
+}
+
+// Scan implements the sql.Scanner interface
+func (e *SchemaErrors) Scan(value interface{}) error {
+	if value == nil {
+		*e = nil
+		return nil
+	}
+	return scanJSON(value, e)
+}
+
+// scanJSON decodes a JSON column, which PostgreSQL returns as bytes and
+// SQLite as a string
+func scanJSON(value interface{}, v interface{}) error {
+	switch data := value.(type) {
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", value, v)
+	}
+}
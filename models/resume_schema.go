@@ -0,0 +1,418 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ApplySchema normalizes the resume's metadata into Profile and flags the
+// resume as invalid when the metadata does not fit the schema
+func (r *Resume) ApplySchema() {
+	profile, errs := NormalizeProfile(r.Metadata)
+	r.Profile = profile
+	r.SchemaVersion = ResumeSchemaVersion
+	r.SchemaErrors = errs
+	r.SchemaStatus = SchemaValid
+	if len(errs) > 0 {
+		r.SchemaStatus = SchemaInvalid
+	}
+}
+
+// NormalizeProfile maps free-form parser metadata onto the resume schema.
+// Keys are matched case-insensitively, ignoring spaces and punctuation, and
+// common alternative names are accepted, such as "work_experience" for
+// "experience" or "employer" for "company". Values that cannot be
+// interpreted are left out of the profile and reported as errors.
+func NormalizeProfile(metadata JSONB) (*ResumeProfile, SchemaErrors) {
+	n := &normalizer{}
+	fields := newFields(metadata)
+
+	profile := &ResumeProfile{
+		SchemaVersion:  ResumeSchemaVersion,
+		Experience:     []Experience{},
+		Education:      []Education{},
+		Skills:         []string{},
+		Certifications: []Certification{},
+		Languages:      []Language{},
+	}
+
+	// Contact details may be top-level or grouped, the group taking
+	// precedence
+	contact := fields
+	if v, ok := fields.lookup("contact", "contactinfo", "contactinformation", "personalinfo", "personalinformation"); ok {
+		if object, ok := n.object("contact", v); ok {
+			contact = newFields(metadata)
+			for key, value := range object {
+				contact[canonicalKey(key)] = value
+			}
+		}
+	}
+	profile.Contact = n.contact(contact)
+	profile.Summary = n.text("summary", fields.get("summary", "objective", "about", "professionalsummary", "careerobjective"))
+
+	for i, v := range n.list("experience", fields.get("experience", "workexperience", "employment", "employmenthistory", "workhistory", "positions", "jobs")) {
+		if object, ok := n.object(index("experience", i), v); ok {
+			profile.Experience = append(profile.Experience, n.experience(index("experience", i), object))
+		}
+	}
+	for i, v := range n.list("education", fields.get("education", "educations", "academics", "academicbackground")) {
+		if object, ok := n.object(index("education", i), v); ok {
+			profile.Education = append(profile.Education, n.education(index("education", i), object))
+		}
+	}
+	profile.Skills = n.skills(fields.get("skills", "technicalskills", "skillset", "competencies"))
+	for i, v := range n.list("certifications", fields.get("certifications", "certificates", "licenses", "certs")) {
+		if cert, ok := n.certification(index("certifications", i), v); ok {
+			profile.Certifications = append(profile.Certifications, cert)
+		}
+	}
+	for i, v := range n.list("languages", fields.get("languages", "spokenlanguages")) {
+		if language, ok := n.language(index("languages", i), v); ok {
+			profile.Languages = append(profile.Languages, language)
+		}
+	}
+
+	return profile, n.errs
+}
+
+// normalizer collects the errors found while normalizing
+type normalizer struct {
+	errs SchemaErrors
+}
+
+func (n *normalizer) fail(field, message string) {
+	n.errs = append(n.errs, SchemaError{Field: field, Message: message})
+}
+
+func index(field string, i int) string {
+	return fmt.Sprintf("%s[%d]", field, i)
+}
+
+// fields indexes an object by canonical key
+type fields map[string]interface{}
+
+func newFields(object map[string]interface{}) fields {
+	f := fields{}
+	for key, value := range object {
+		f[canonicalKey(key)] = value
+	}
+	return f
+}
+
+// canonicalKey lower-cases a key and drops everything but letters and
+// digits, so that "Job Title", "job_title" and "jobTitle" match
+func canonicalKey(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// lookup returns the value of the first alias present
+func (f fields) lookup(aliases ...string) (interface{}, bool) {
+	for _, alias := range aliases {
+		if v, ok := f[alias]; ok && v != nil {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func (f fields) get(aliases ...string) interface{} {
+	v, _ := f.lookup(aliases...)
+	return v
+}
+
+// text returns a trimmed string, accepting numbers as well since parsers
+// emit years and grades either way
+func (n *normalizer) text(field string, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.Join(strings.Fields(v), " ")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		n.fail(field, "must be text")
+		return ""
+	}
+}
+
+// texts returns a list of strings. A single string is split into lines,
+// dropping bullet characters.
+func (n *normalizer) texts(field string, v interface{}) []string {
+	var out []string
+	if s, ok := v.(string); ok {
+		for _, line := range strings.Split(s, "\n") {
+			if line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "•*-–")); line != "" {
+				out = append(out, line)
+			}
+		}
+		return out
+	}
+	for i, item := range n.list(field, v) {
+		if s := n.text(index(field, i), item); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (n *normalizer) list(field string, v interface{}) []interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	default:
+		n.fail(field, "must be a list")
+		return nil
+	}
+}
+
+func (n *normalizer) object(field string, v interface{}) (fields, bool) {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		n.fail(field, "must be an object")
+		return nil, false
+	}
+	return newFields(object), true
+}
+
+func (n *normalizer) contact(f fields) Contact {
+	contact := Contact{
+		Name:     n.text("contact.name", f.get("name", "fullname", "candidatename")),
+		Email:    n.text("contact.email", f.get("email", "emailaddress", "mail")),
+		Phone:    n.text("contact.phone", f.get("phone", "phonenumber", "mobile", "telephone")),
+		Location: n.text("contact.location", f.get("location", "address", "city")),
+	}
+	if contact.Name == "" {
+		n.fail("contact.name", "is required")
+	}
+	if contact.Email != "" {
+		if address, err := mail.ParseAddress(contact.Email); err != nil || address.Address != contact.Email {
+			n.fail("contact.email", "is not a valid email address")
+		}
+	}
+
+	for _, key := range []string{"links", "urls", "websites", "website", "linkedin", "github", "portfolio"} {
+		for _, link := range n.texts("contact."+key, f.get(key)) {
+			if !strings.Contains(link, "://") {
+				link = "https://" + link
+			}
+			if u, err := url.Parse(link); err != nil || u.Host == "" {
+				n.fail("contact."+key, fmt.Sprintf("%q is not a valid URL", link))
+				continue
+			}
+			contact.Links = append(contact.Links, link)
+		}
+	}
+	return contact
+}
+
+// dateRangeSeparator splits ranges such as "2019 - 2021" or "Jan 2019 to
+// Present"; a hyphen needs spaces around it so that "2019-01" stays whole
+var dateRangeSeparator = regexp.MustCompile(`\s+(?:-|–|—|to)\s+|\s*[–—]\s*`)
+
+func (n *normalizer) experience(field string, f fields) Experience {
+	e := Experience{
+		Title:       n.text(field+".title", f.get("title", "position", "role", "jobtitle", "designation")),
+		Company:     n.text(field+".company", f.get("company", "employer", "organization", "organisation", "companyname")),
+		Location:    n.text(field+".location", f.get("location", "city")),
+		Description: n.text(field+".description", f.get("description", "summary", "details")),
+		Highlights:  n.texts(field+".highlights", f.get("highlights", "responsibilities", "achievements", "bullets")),
+	}
+	if e.Title == "" && e.Company == "" {
+		n.fail(field, "needs a title or a company")
+	}
+
+	start, end := f.get("startdate", "start", "from", "since"), f.get("enddate", "end", "to", "until")
+	if start == nil && end == nil {
+		if period := n.text(field+".dates", f.get("dates", "period", "duration")); period != "" {
+			if parts := dateRangeSeparator.Split(period, 2); len(parts) == 2 {
+				start, end = parts[0], parts[1]
+			} else {
+				start = period
+			}
+		}
+	}
+	e.StartDate, _ = n.date(field+".start_date", start)
+	var present bool
+	e.EndDate, present = n.date(field+".end_date", end)
+	if current, ok := f.get("current", "iscurrent", "currentlyworking").(bool); ok {
+		present = present || current
+	}
+	e.Current = present && e.EndDate == ""
+	n.checkOrder(field, e.StartDate, e.EndDate)
+	return e
+}
+
+func (n *normalizer) education(field string, f fields) Education {
+	e := Education{
+		Institution: n.text(field+".institution", f.get("institution", "school", "university", "college", "institute")),
+		Degree:      n.text(field+".degree", f.get("degree", "qualification", "diploma")),
+		Field:       n.text(field+".field", f.get("field", "fieldofstudy", "major", "studyfield", "subject")),
+		Grade:       n.text(field+".grade", f.get("grade", "gpa", "score")),
+	}
+	if e.Institution == "" {
+		n.fail(field+".institution", "is required")
+	}
+	e.StartDate, _ = n.date(field+".start_date", f.get("startdate", "start", "from"))
+	e.EndDate, _ = n.date(field+".end_date", f.get("enddate", "end", "to", "graduationdate", "graduated", "year"))
+	n.checkOrder(field, e.StartDate, e.EndDate)
+	return e
+}
+
+// skills accepts a list, a comma-separated string, or an object of
+// categories each holding skills. Duplicates are dropped, keeping the
+// first spelling.
+func (n *normalizer) skills(v interface{}) []string {
+	var raw []string
+	switch v := v.(type) {
+	case nil:
+	case string:
+		raw = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' || r == '\n' })
+	case map[string]interface{}:
+		for _, category := range sortedKeys(v) {
+			raw = append(raw, n.skills(v[category])...)
+		}
+	default:
+		for i, item := range n.list("skills", v) {
+			if object, ok := item.(map[string]interface{}); ok {
+				item = newFields(object).get("name", "skill")
+			}
+			raw = append(raw, n.text(index("skills", i), item))
+		}
+	}
+
+	skills := []string{}
+	seen := map[string]bool{}
+	for _, skill := range raw {
+		skill = strings.Join(strings.Fields(skill), " ")
+		if skill == "" || seen[strings.ToLower(skill)] {
+			continue
+		}
+		seen[strings.ToLower(skill)] = true
+		skills = append(skills, skill)
+	}
+	return skills
+}
+
+func (n *normalizer) certification(field string, v interface{}) (Certification, bool) {
+	var cert Certification
+	if s, ok := v.(string); ok {
+		cert.Name = n.text(field, s)
+	} else {
+		f, ok := n.object(field, v)
+		if !ok {
+			return cert, false
+		}
+		cert.Name = n.text(field+".name", f.get("name", "title", "certification"))
+		cert.Issuer = n.text(field+".issuer", f.get("issuer", "authority", "organization", "issuedby"))
+		cert.Date, _ = n.date(field+".date", f.get("date", "issued", "issuedate", "year"))
+	}
+	if cert.Name == "" {
+		n.fail(field+".name", "is required")
+		return cert, false
+	}
+	return cert, true
+}
+
+// languageLevel matches "German (fluent)"
+var languageLevel = regexp.MustCompile(`^(.+?)\s*\((.+)\)$`)
+
+func (n *normalizer) language(field string, v interface{}) (Language, bool) {
+	var language Language
+	if s, ok := v.(string); ok {
+		language.Name = n.text(field, s)
+		if m := languageLevel.FindStringSubmatch(language.Name); m != nil {
+			language.Name, language.Proficiency = m[1], m[2]
+		}
+	} else {
+		f, ok := n.object(field, v)
+		if !ok {
+			return language, false
+		}
+		language.Name = n.text(field+".name", f.get("name", "language"))
+		language.Proficiency = n.text(field+".proficiency", f.get("proficiency", "level", "fluency"))
+	}
+	language.Proficiency = strings.ToLower(language.Proficiency)
+	if language.Name == "" {
+		n.fail(field+".name", "is required")
+		return language, false
+	}
+	return language, true
+}
+
+// dateLayouts are the date spellings accepted, with whether they include
+// the month
+var dateLayouts = []struct {
+	layout string
+	month  bool
+}{
+	{"2006-01-02", true},
+	{"2006-01", true},
+	{"2006/01", true},
+	{"01/2006", true},
+	{"1/2006", true},
+	{"01.2006", true},
+	{"Jan 2006", true},
+	{"January 2006", true},
+	{"Jan. 2006", true},
+	{"2006", false},
+}
+
+// date normalizes a date to YYYY-MM or YYYY. present reports an end date
+// such as "Present" that marks an ongoing position.
+func (n *normalizer) date(field string, v interface{}) (date string, present bool) {
+	s := n.text(field, v)
+	if s == "" {
+		return "", false
+	}
+	switch strings.ToLower(s) {
+	case "present", "current", "now", "ongoing", "today":
+		return "", true
+	}
+	for _, l := range dateLayouts {
+		if t, err := time.Parse(l.layout, s); err == nil {
+			if l.month {
+				return t.Format("2006-01"), false
+			}
+			return t.Format("2006"), false
+		}
+	}
+	n.fail(field, fmt.Sprintf("%q is not a recognized date", s))
+	return "", false
+}
+
+// checkOrder reports an end date before the start date. Dates of different
+// precision are compared on the parts both have.
+func (n *normalizer) checkOrder(field, start, end string) {
+	if start == "" || end == "" {
+		return
+	}
+	length := min(len(start), len(end))
+	if end[:length] < start[:length] {
+		n.fail(field+".end_date", "is before the start date")
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decodeMetadata(t *testing.T, s string) JSONB {
+	t.Helper()
+	var metadata JSONB
+	if err := json.Unmarshal([]byte(s), &metadata); err != nil {
+		t.Fatal(err)
+	}
+	return metadata
+}
+
+func TestNormalizeProfile(t *testing.T) {
+	metadata := decodeMetadata(t, `{
+		"Full Name": "  Alice   Smith ",
+		"contact": {"email": "alice@example.com", "phoneNumber": "+1 555 0100", "linkedin": "linkedin.com/in/alice"},
+		"objective": "Backend engineer",
+		"work_experience": [
+			{"jobTitle": "Senior Engineer", "employer": "Globex", "dates": "Mar 2021 - Present", "responsibilities": "• Led the API team\n• Ran on-call"},
+			{"position": "Engineer", "company": "Acme", "start_date": "2018-01-15", "end_date": "02/2021"}
+		],
+		"education": [{"university": "TU Berlin", "degree": "MSc", "major": "Computer Science", "graduated": 2018, "gpa": 1.3}],
+		"skills": {"languages": ["Go", "SQL"], "tools": "docker, go"},
+		"certificates": ["CKA", {"title": "AWS Developer", "issuer": "Amazon", "year": "2022"}],
+		"languages": ["German (Native)", {"language": "English", "level": "C1"}],
+		"hobbies": ["chess"]
+	}`)
+
+	profile, errs := NormalizeProfile(metadata)
+	if len(errs) != 0 {
+		t.Fatalf("errors = %+v", errs)
+	}
+	want := &ResumeProfile{
+		SchemaVersion: ResumeSchemaVersion,
+		Contact: Contact{
+			Name:  "Alice Smith",
+			Email: "alice@example.com",
+			Phone: "+1 555 0100",
+			Links: []string{"https://linkedin.com/in/alice"},
+		},
+		Summary: "Backend engineer",
+		Experience: []Experience{
+			{Title: "Senior Engineer", Company: "Globex", StartDate: "2021-03", Current: true, Highlights: []string{"Led the API team", "Ran on-call"}},
+			{Title: "Engineer", Company: "Acme", StartDate: "2018-01", EndDate: "2021-02"},
+		},
+		Education:      []Education{{Institution: "TU Berlin", Degree: "MSc", Field: "Computer Science", EndDate: "2018", Grade: "1.3"}},
+		Skills:         []string{"Go", "SQL", "docker"},
+		Certifications: []Certification{{Name: "CKA"}, {Name: "AWS Developer", Issuer: "Amazon", Date: "2022"}},
+		Languages:      []Language{{Name: "German", Proficiency: "native"}, {Name: "English", Proficiency: "c1"}},
+	}
+	if !reflect.DeepEqual(profile, want) {
+		t.Errorf("profile =\n%+v\nwant\n%+v", profile, want)
+	}
+}
+
+func TestNormalizeProfileErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		metadata string
+		want     SchemaErrors
+	}{
+		{"empty", `{}`, SchemaErrors{{"contact.name", "is required"}}},
+		{"bad email", `{"name": "A", "email": "not an address"}`, SchemaErrors{{"contact.email", "is not a valid email address"}}},
+		{"experience not a list", `{"name": "A", "experience": "Acme"}`, SchemaErrors{{"experience", "must be a list"}}},
+		{"experience item not an object", `{"name": "A", "experience": ["Acme"]}`, SchemaErrors{{"experience[0]", "must be an object"}}},
+		{"experience without title or company", `{"name": "A", "experience": [{"location": "Remote"}]}`, SchemaErrors{{"experience[0]", "needs a title or a company"}}},
+		{"unknown date", `{"name": "A", "experience": [{"title": "Dev", "start": "last spring"}]}`, SchemaErrors{{"experience[0].start_date", `"last spring" is not a recognized date`}}},
+		{"end before start", `{"name": "A", "experience": [{"title": "Dev", "start": "2020-05", "end": "2019"}]}`, SchemaErrors{{"experience[0].end_date", "is before the start date"}}},
+		{"education without institution", `{"name": "A", "education": [{"degree": "BSc"}]}`, SchemaErrors{{"education[0].institution", "is required"}}},
+		{"name of the wrong type", `{"name": {"first": "A"}}`, SchemaErrors{{"contact.name", "must be text"}, {"contact.name", "is required"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, errs := NormalizeProfile(decodeMetadata(t, tc.metadata))
+			if !reflect.DeepEqual(errs, tc.want) {
+				t.Errorf("errors = %+v, want %+v", errs, tc.want)
+			}
+		})
+	}
+}
+
+func TestApplySchema(t *testing.T) {
+	resume := Resume{Metadata: JSONB{"name": "Alice", "skills": []interface{}{"go"}}}
+	resume.ApplySchema()
+	if resume.SchemaStatus != SchemaValid || resume.SchemaVersion != ResumeSchemaVersion || resume.Profile.Contact.Name != "Alice" || resume.SchemaErrors != nil {
+		t.Errorf("valid resume = %+v", resume)
+	}
+
+	resume.Metadata = JSONB{"skills": []interface{}{"go"}}
+	resume.ApplySchema()
+	if resume.SchemaStatus != SchemaInvalid || len(resume.SchemaErrors) != 1 || !reflect.DeepEqual(resume.Profile.Skills, []string{"go"}) {
+		t.Errorf("invalid resume = %+v", resume)
+	}
+}
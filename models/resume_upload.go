@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// Resume upload statuses
+const (
+	UploadPending   = "pending"
+	UploadCompleted = "completed"
+	UploadRejected  = "rejected"
+)
+
+// ResumeUpload records a presigned upload URL handed out to a client. Size
+// and ContentType are what the client declared; confirming the upload checks
+// the stored file against them.
+// @Description Resume upload information
+type ResumeUpload struct {
+	ID       uint   `json:"id" gorm:"primaryKey" example:"1"`
+	Key      string `json:"key" gorm:"not null;index" example:"resumes/cv.pdf"`
+	FileName string `json:"file_name" gorm:"not null" example:"cv.pdf"`
+	// OwnerID and APIKeyID identify the API key the URL was issued to
+	OwnerID     string     `json:"owner_id" gorm:"not null;index" example:"team-recruiting"`
+	APIKeyID    uint       `json:"api_key_id" example:"1"`
+	Status      string     `json:"status" gorm:"size:16;not null;default:pending" example:"pending"`
+	Size        int64      `json:"size,omitempty" example:"48213"`
+	ContentType string     `json:"content_type,omitempty" example:"application/pdf"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"not null"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"not null"`
+}
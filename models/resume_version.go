@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// Resume change actions
+const (
+	ResumeCreated  = "created"
+	ResumeUpdated  = "updated"
+	ResumeRestored = "restored"
+)
+
+// ResumeChange describes who changed a resume and how
+type ResumeChange struct {
+	Action string `json:"action" gorm:"size:16;not null" example:"updated"`
+	// APIKeyID and ChangedBy identify the API key that made the change and
+	// its owner; both are empty for changes made before versions existed
+	APIKeyID  *uint  `json:"api_key_id,omitempty" example:"1"`
+	ChangedBy string `json:"changed_by" gorm:"not null;default:''" example:"team-recruiting"`
+	// RestoredFrom is the version a restore copied
+	RestoredFrom *int `json:"restored_from,omitempty" example:"2"`
+}
+
+// ResumeVersion is a snapshot of a resume's text and metadata, taken every
+// time the resume is created or changed. Versions are numbered from 1 per
+// resume.
+// @Description Resume version information
+type ResumeVersion struct {
+	ID           uint   `json:"-" gorm:"primaryKey"`
+	ResumeID     uint   `json:"resume_id" gorm:"not null;uniqueIndex:idx_resume_versions_resume_version" example:"1"`
+	Version      int    `json:"version" gorm:"not null;uniqueIndex:idx_resume_versions_resume_version" example:"3"`
+	RawText      string `json:"raw_text" gorm:"type:text;not null"`
+	Metadata     JSONB  `json:"metadata" gorm:"type:jsonb"`
+	ResumeChange `gorm:"embedded"`
+	CreatedAt    time.Time `json:"created_at" gorm:"not null"`
+}
+
+// ResumeVersionSummary is the projection of a version used in listings,
+// without the raw text
+type ResumeVersionSummary struct {
+	ResumeID     uint  `json:"resume_id" example:"1"`
+	Version      int   `json:"version" example:"3"`
+	Metadata     JSONB `json:"metadata"`
+	ResumeChange `gorm:"embedded"`
+	CreatedAt    time.Time `json:"created_at"`
+}
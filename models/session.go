@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// Session statuses
+const (
+	SessionStatusActive  = "active"
+	SessionStatusExpired = "expired"
+)
+
+// Session represents a chat session with the upstream chat service
+// @Description Chat session information
+type Session struct {
+	ID           string    `json:"id" gorm:"primaryKey;size:64" example:"session-4f1c2a9e0b7d4c3e8a6f5b2d1e0c9a8b"`
+	OwnerID      string    `json:"owner_id" gorm:"not null;index" example:"user-1"`
+	ResumeID     *uint     `json:"resume_id" example:"1"`
+	Status       string    `json:"status" gorm:"not null;default:active" example:"active"`
+	CreatedAt    time.Time `json:"created_at" gorm:"not null"`
+	LastActiveAt time.Time `json:"last_active_at" gorm:"not null"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null;index"`
+}
+
+// Expired reports whether the session can no longer be used at t
+func (s *Session) Expired(t time.Time) bool {
+	return s.Status == SessionStatusExpired || !t.Before(s.ExpiresAt)
+}
@@ -0,0 +1,384 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go-server/models"
+
+	"gorm.io/gorm"
+)
+
+// GormProducts stores products with GORM
+type GormProducts struct {
+	db *gorm.DB
+}
+
+func NewGormProducts(db *gorm.DB) *GormProducts {
+	return &GormProducts{db: db}
+}
+
+var productColumns = map[string]bool{
+	"id": true, "title": true, "seller_id": true, "created_at": true, "updated_at": true,
+}
+
+func (r *GormProducts) List(ctx context.Context, filter ProductFilter, opts ListOptions) ([]models.Product, int64, error) {
+	order, err := orderClause(opts.Sort, productColumns)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filters := []func(*gorm.DB) *gorm.DB{
+		timeRangeScope("created_at", filter.Created),
+		timeRangeScope("updated_at", filter.Updated),
+	}
+	if filter.SellerID != nil {
+		filters = append(filters, func(db *gorm.DB) *gorm.DB {
+			return db.Where("seller_id = ?", *filter.SellerID)
+		})
+	}
+	if filter.Title != "" {
+		filters = append(filters, func(db *gorm.DB) *gorm.DB {
+			return db.Where(`LOWER(title) LIKE ? ESCAPE '\'`, "%"+escapeLike(strings.ToLower(filter.Title))+"%")
+		})
+	}
+
+	db := r.db.WithContext(ctx)
+	var total int64
+	if err := db.Model(&models.Product{}).Scopes(filters...).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	products := []models.Product{}
+	if err := db.Scopes(filters...).Scopes(pageScope(opts)).Order(order).Find(&products).Error; err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+func (r *GormProducts) Get(ctx context.Context, id uint) (*models.Product, error) {
+	var product models.Product
+	if err := r.db.WithContext(ctx).First(&product, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &product, nil
+}
+
+func (r *GormProducts) Create(ctx context.Context, product *models.Product) error {
+	return r.db.WithContext(ctx).Create(product).Error
+}
+
+func (r *GormProducts) Update(ctx context.Context, product *models.Product) error {
+	return r.db.WithContext(ctx).Save(product).Error
+}
+
+func (r *GormProducts) Delete(ctx context.Context, id uint) error {
+	return deleteByID(r.db.WithContext(ctx), &models.Product{}, id)
+}
+
+// GormResumes stores resumes with GORM. Metadata filters use jsonb
+// operators and therefore require PostgreSQL.
+type GormResumes struct {
+	db *gorm.DB
+}
+
+func NewGormResumes(db *gorm.DB) *GormResumes {
+	return &GormResumes{db: db}
+}
+
+var resumeColumns = map[string]bool{
+	"id": true, "user_id": true, "created_at": true, "updated_at": true,
+}
+
+func (r *GormResumes) List(ctx context.Context, filter ResumeFilter, opts ListOptions) ([]models.ResumeSummary, int64, error) {
+	order, err := orderClause(opts.Sort, resumeColumns)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filters := []func(*gorm.DB) *gorm.DB{timeRangeScope("created_at", filter.Created)}
+	if filter.UserID != "" {
+		filters = append(filters, func(db *gorm.DB) *gorm.DB {
+			return db.Where("user_id = ?", filter.UserID)
+		})
+	}
+	if filter.SchemaStatus != "" {
+		filters = append(filters, func(db *gorm.DB) *gorm.DB {
+			return db.Where("schema_status = ?", filter.SchemaStatus)
+		})
+	}
+	for key, values := range filter.Metadata {
+		for _, value := range values {
+			filters = append(filters, metadataContains(key, value))
+		}
+	}
+
+	columns := []string{"id", "user_id", "metadata", "schema_status", "created_at", "updated_at"}
+	if filter.IncludeRawText {
+		columns = append(columns, "raw_text")
+	}
+
+	db := r.db.WithContext(ctx)
+	var total int64
+	if err := db.Model(&models.Resume{}).Scopes(filters...).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	resumes := []models.ResumeSummary{}
+	if err := db.Model(&models.Resume{}).Select(columns).Scopes(filters...).Scopes(pageScope(opts)).Order(order).Find(&resumes).Error; err != nil {
+		return nil, 0, err
+	}
+	return resumes, total, nil
+}
+
+// metadataContains matches resumes whose top-level metadata key equals the
+// value or, when the key holds an array, contains it
+func metadataContains(key, value string) func(*gorm.DB) *gorm.DB {
+	needle := value
+	if !json.Valid([]byte(value)) {
+		encoded, _ := json.Marshal(value)
+		needle = string(encoded)
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("metadata -> ? @> ?::jsonb", key, needle)
+	}
+}
+
+func (r *GormResumes) Get(ctx context.Context, id uint) (*models.Resume, error) {
+	var resume models.Resume
+	if err := r.db.WithContext(ctx).First(&resume, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &resume, nil
+}
+
+func (r *GormResumes) Latest(ctx context.Context) (*models.Resume, error) {
+	var resume models.Resume
+	if err := r.db.WithContext(ctx).Order("id desc").First(&resume).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &resume, nil
+}
+
+func (r *GormResumes) Create(ctx context.Context, resume *models.Resume, change models.ResumeChange) error {
+	resume.ApplySchema()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(resume).Error; err != nil {
+			return err
+		}
+		return addVersion(tx, resume, change)
+	})
+}
+
+func (r *GormResumes) Update(ctx context.Context, resume *models.Resume, change models.ResumeChange) error {
+	resume.ApplySchema()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(resume).Error; err != nil {
+			return err
+		}
+		return addVersion(tx, resume, change)
+	})
+}
+
+// addVersion records the resume's current content as its next version.
+// Concurrent changes of one resume collide on the unique (resume_id,
+// version) index, so one of them fails instead of both sharing a number.
+func addVersion(tx *gorm.DB, resume *models.Resume, change models.ResumeChange) error {
+	var latest int
+	if err := tx.Model(&models.ResumeVersion{}).Where("resume_id = ?", resume.ID).Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.ResumeVersion{
+		ResumeID:     resume.ID,
+		Version:      latest + 1,
+		RawText:      resume.RawText,
+		Metadata:     resume.Metadata,
+		ResumeChange: change,
+	}).Error
+}
+
+func (r *GormResumes) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("resume_id = ?", id).Delete(&models.ResumeVersion{}).Error; err != nil {
+			return err
+		}
+		return deleteByID(tx, &models.Resume{}, id)
+	})
+}
+
+func (r *GormResumes) Versions(ctx context.Context, resumeID uint, opts ListOptions) ([]models.ResumeVersionSummary, int64, error) {
+	db := r.db.WithContext(ctx)
+	var total int64
+	if err := db.Model(&models.ResumeVersion{}).Where("resume_id = ?", resumeID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	versions := []models.ResumeVersionSummary{}
+	if err := db.Model(&models.ResumeVersion{}).Where("resume_id = ?", resumeID).Scopes(pageScope(opts)).Order("version asc").Find(&versions).Error; err != nil {
+		return nil, 0, err
+	}
+	return versions, total, nil
+}
+
+func (r *GormResumes) Version(ctx context.Context, resumeID uint, version int) (*models.ResumeVersion, error) {
+	var v models.ResumeVersion
+	if err := r.db.WithContext(ctx).Where("resume_id = ? AND version = ?", resumeID, version).First(&v).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &v, nil
+}
+
+// GormUploads stores resume upload intents with GORM
+type GormUploads struct {
+	db *gorm.DB
+}
+
+func NewGormUploads(db *gorm.DB) *GormUploads {
+	return &GormUploads{db: db}
+}
+
+func (r *GormUploads) Create(ctx context.Context, upload *models.ResumeUpload) error {
+	return r.db.WithContext(ctx).Create(upload).Error
+}
+
+func (r *GormUploads) Get(ctx context.Context, id uint) (*models.ResumeUpload, error) {
+	var upload models.ResumeUpload
+	if err := r.db.WithContext(ctx).First(&upload, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &upload, nil
+}
+
+func (r *GormUploads) GetByKey(ctx context.Context, key string) (*models.ResumeUpload, error) {
+	var upload models.ResumeUpload
+	if err := r.db.WithContext(ctx).Where("key = ?", key).Order("id DESC").First(&upload).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &upload, nil
+}
+
+func (r *GormUploads) Update(ctx context.Context, upload *models.ResumeUpload) error {
+	return r.db.WithContext(ctx).Save(upload).Error
+}
+
+// GormSessions stores chat sessions and messages with GORM
+type GormSessions struct {
+	db *gorm.DB
+}
+
+func NewGormSessions(db *gorm.DB) *GormSessions {
+	return &GormSessions{db: db}
+}
+
+func (r *GormSessions) Create(ctx context.Context, session *models.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *GormSessions) Get(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.WithContext(ctx).First(&session, "id = ?", id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &session, nil
+}
+
+func (r *GormSessions) Update(ctx context.Context, session *models.Session) error {
+	return r.db.WithContext(ctx).Model(session).Updates(map[string]interface{}{
+		"status":         session.Status,
+		"last_active_at": session.LastActiveAt,
+		"expires_at":     session.ExpiresAt,
+	}).Error
+}
+
+func (r *GormSessions) AddMessages(ctx context.Context, messages ...models.ChatMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&messages).Error
+}
+
+func (r *GormSessions) ListMessages(ctx context.Context, sessionID string, opts ListOptions) ([]models.ChatMessage, int64, error) {
+	db := r.db.WithContext(ctx)
+	var total int64
+	if err := db.Model(&models.ChatMessage{}).Where("session_id = ?", sessionID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	messages := []models.ChatMessage{}
+	if err := db.Where("session_id = ?", sessionID).Scopes(pageScope(opts)).Order("created_at asc, id asc").Find(&messages).Error; err != nil {
+		return nil, 0, err
+	}
+	return messages, total, nil
+}
+
+// notFound translates GORM's missing record error
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// deleteByID deletes the record of model's table with id, reporting
+// ErrNotFound when there is none
+func deleteByID(db *gorm.DB, model interface{}, id uint) error {
+	result := db.Delete(model, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// orderClause builds an ORDER BY clause from sort fields, accepting only
+// allowed columns. The id column breaks ties so that pages are stable.
+func orderClause(fields []SortField, allowed map[string]bool) (string, error) {
+	var clauses []string
+	hasID := false
+	for _, field := range fields {
+		if !allowed[field.Column] {
+			return "", fmt.Errorf("cannot sort by %q", field.Column)
+		}
+		direction := "asc"
+		if field.Desc {
+			direction = "desc"
+		}
+		clauses = append(clauses, field.Column+" "+direction)
+		hasID = hasID || field.Column == "id"
+	}
+	if !hasID {
+		clauses = append(clauses, "id asc")
+	}
+	return strings.Join(clauses, ", "), nil
+}
+
+func pageScope(opts ListOptions) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if opts.Limit > 0 {
+			db = db.Limit(opts.Limit)
+		}
+		return db.Offset(opts.Offset)
+	}
+}
+
+func timeRangeScope(column string, r TimeRange) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if r.After != nil {
+			db = db.Where(column+" >= ?", *r.After)
+		}
+		if r.Before != nil {
+			db = db.Where(column+" < ?", *r.Before)
+		}
+		return db
+	}
+}
+
+// escapeLike escapes the LIKE wildcards in a user supplied substring
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,473 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-server/models"
+)
+
+// MemoryProducts is an in-memory ProductRepository for tests
+type MemoryProducts struct {
+	mu       sync.Mutex
+	nextID   uint
+	products map[uint]models.Product
+}
+
+func NewMemoryProducts() *MemoryProducts {
+	return &MemoryProducts{products: map[uint]models.Product{}}
+}
+
+var productComparators = map[string]func(a, b models.Product) int{
+	"id":         func(a, b models.Product) int { return cmp.Compare(a.ID, b.ID) },
+	"title":      func(a, b models.Product) int { return strings.Compare(a.Title, b.Title) },
+	"seller_id":  func(a, b models.Product) int { return cmp.Compare(a.SellerID, b.SellerID) },
+	"created_at": func(a, b models.Product) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"updated_at": func(a, b models.Product) int { return a.UpdatedAt.Compare(b.UpdatedAt) },
+}
+
+func (r *MemoryProducts) List(ctx context.Context, filter ProductFilter, opts ListOptions) ([]models.Product, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	title := strings.ToLower(filter.Title)
+	var matched []models.Product
+	for _, p := range r.products {
+		if filter.SellerID != nil && p.SellerID != *filter.SellerID {
+			continue
+		}
+		if title != "" && !strings.Contains(strings.ToLower(p.Title), title) {
+			continue
+		}
+		if !filter.Created.contains(p.CreatedAt) || !filter.Updated.contains(p.UpdatedAt) {
+			continue
+		}
+		matched = append(matched, p)
+	}
+
+	if err := sortBy(matched, opts.Sort, productComparators); err != nil {
+		return nil, 0, err
+	}
+	return page(matched, opts), int64(len(matched)), nil
+}
+
+func (r *MemoryProducts) Get(ctx context.Context, id uint) (*models.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.products[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &p, nil
+}
+
+func (r *MemoryProducts) Create(ctx context.Context, product *models.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	now := time.Now()
+	product.ID = r.nextID
+	product.CreatedAt = now
+	product.UpdatedAt = now
+	r.products[product.ID] = *product
+	return nil
+}
+
+func (r *MemoryProducts) Update(ctx context.Context, product *models.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.products[product.ID]; !ok {
+		return ErrNotFound
+	}
+	product.UpdatedAt = time.Now()
+	r.products[product.ID] = *product
+	return nil
+}
+
+func (r *MemoryProducts) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.products[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.products, id)
+	return nil
+}
+
+// MemoryResumes is an in-memory ResumeRepository for tests
+type MemoryResumes struct {
+	mu       sync.Mutex
+	nextID   uint
+	resumes  map[uint]models.Resume
+	versions map[uint][]models.ResumeVersion
+}
+
+func NewMemoryResumes() *MemoryResumes {
+	return &MemoryResumes{resumes: map[uint]models.Resume{}, versions: map[uint][]models.ResumeVersion{}}
+}
+
+var resumeComparators = map[string]func(a, b models.ResumeSummary) int{
+	"id":         func(a, b models.ResumeSummary) int { return cmp.Compare(a.ID, b.ID) },
+	"user_id":    func(a, b models.ResumeSummary) int { return strings.Compare(a.UserID, b.UserID) },
+	"created_at": func(a, b models.ResumeSummary) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"updated_at": func(a, b models.ResumeSummary) int { return a.UpdatedAt.Compare(b.UpdatedAt) },
+}
+
+func (r *MemoryResumes) List(ctx context.Context, filter ResumeFilter, opts ListOptions) ([]models.ResumeSummary, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []models.ResumeSummary
+	for _, resume := range r.resumes {
+		if filter.UserID != "" && resume.UserID != filter.UserID {
+			continue
+		}
+		if filter.SchemaStatus != "" && resume.SchemaStatus != filter.SchemaStatus {
+			continue
+		}
+		if !filter.Created.contains(resume.CreatedAt) || !metadataMatches(resume.Metadata, filter.Metadata) {
+			continue
+		}
+		summary := models.ResumeSummary{
+			ID:           resume.ID,
+			UserID:       resume.UserID,
+			Metadata:     resume.Metadata,
+			SchemaStatus: resume.SchemaStatus,
+			CreatedAt:    resume.CreatedAt,
+			UpdatedAt:    resume.UpdatedAt,
+		}
+		if filter.IncludeRawText {
+			summary.RawText = resume.RawText
+		}
+		matched = append(matched, summary)
+	}
+
+	if err := sortBy(matched, opts.Sort, resumeComparators); err != nil {
+		return nil, 0, err
+	}
+	return page(matched, opts), int64(len(matched)), nil
+}
+
+// metadataMatches mirrors the jsonb containment used by GormResumes
+func metadataMatches(metadata models.JSONB, filters map[string][]string) bool {
+	for key, values := range filters {
+		for _, value := range values {
+			var needle interface{}
+			if err := json.Unmarshal([]byte(value), &needle); err != nil {
+				needle = value
+			}
+			actual, ok := metadata[key]
+			if !ok {
+				return false
+			}
+			if reflect.DeepEqual(actual, needle) {
+				continue
+			}
+			list, ok := actual.([]interface{})
+			if !ok || !slices.ContainsFunc(list, func(item interface{}) bool { return reflect.DeepEqual(item, needle) }) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r *MemoryResumes) Get(ctx context.Context, id uint) (*models.Resume, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	resume, ok := r.resumes[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &resume, nil
+}
+
+func (r *MemoryResumes) Latest(ctx context.Context) (*models.Resume, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest *models.Resume
+	for _, resume := range r.resumes {
+		if latest == nil || resume.ID > latest.ID {
+			resume := resume
+			latest = &resume
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return latest, nil
+}
+
+func (r *MemoryResumes) Create(ctx context.Context, resume *models.Resume, change models.ResumeChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	now := time.Now()
+	resume.ID = r.nextID
+	resume.CreatedAt = now
+	resume.UpdatedAt = now
+	resume.ApplySchema()
+	r.resumes[resume.ID] = *resume
+	r.addVersion(resume, change)
+	return nil
+}
+
+func (r *MemoryResumes) Update(ctx context.Context, resume *models.Resume, change models.ResumeChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.resumes[resume.ID]; !ok {
+		return ErrNotFound
+	}
+	resume.ApplySchema()
+	r.resumes[resume.ID] = *resume
+	r.addVersion(resume, change)
+	return nil
+}
+
+// addVersion must be called with r.mu held
+func (r *MemoryResumes) addVersion(resume *models.Resume, change models.ResumeChange) {
+	versions := r.versions[resume.ID]
+	r.versions[resume.ID] = append(versions, models.ResumeVersion{
+		ResumeID:     resume.ID,
+		Version:      len(versions) + 1,
+		RawText:      resume.RawText,
+		Metadata:     resume.Metadata,
+		ResumeChange: change,
+		CreatedAt:    time.Now(),
+	})
+}
+
+func (r *MemoryResumes) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.resumes[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.resumes, id)
+	delete(r.versions, id)
+	return nil
+}
+
+func (r *MemoryResumes) Versions(ctx context.Context, resumeID uint, opts ListOptions) ([]models.ResumeVersionSummary, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := r.versions[resumeID]
+	summaries := make([]models.ResumeVersionSummary, 0, len(versions))
+	for _, v := range versions {
+		summaries = append(summaries, models.ResumeVersionSummary{
+			ResumeID:     v.ResumeID,
+			Version:      v.Version,
+			Metadata:     v.Metadata,
+			ResumeChange: v.ResumeChange,
+			CreatedAt:    v.CreatedAt,
+		})
+	}
+	return page(summaries, opts), int64(len(summaries)), nil
+}
+
+func (r *MemoryResumes) Version(ctx context.Context, resumeID uint, version int) (*models.ResumeVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := r.versions[resumeID]
+	if version < 1 || version > len(versions) {
+		return nil, ErrNotFound
+	}
+	v := versions[version-1]
+	return &v, nil
+}
+
+// MemoryUploads is an in-memory UploadRepository for tests
+type MemoryUploads struct {
+	mu      sync.Mutex
+	nextID  uint
+	uploads map[uint]models.ResumeUpload
+}
+
+func NewMemoryUploads() *MemoryUploads {
+	return &MemoryUploads{uploads: map[uint]models.ResumeUpload{}}
+}
+
+func (r *MemoryUploads) Create(ctx context.Context, upload *models.ResumeUpload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	now := time.Now()
+	upload.ID = r.nextID
+	upload.CreatedAt = now
+	upload.UpdatedAt = now
+	r.uploads[upload.ID] = *upload
+	return nil
+}
+
+func (r *MemoryUploads) Get(ctx context.Context, id uint) (*models.ResumeUpload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	upload, ok := r.uploads[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &upload, nil
+}
+
+func (r *MemoryUploads) GetByKey(ctx context.Context, key string) (*models.ResumeUpload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest *models.ResumeUpload
+	for _, upload := range r.uploads {
+		if upload.Key == key && (latest == nil || upload.ID > latest.ID) {
+			latest = &upload
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return latest, nil
+}
+
+func (r *MemoryUploads) Update(ctx context.Context, upload *models.ResumeUpload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.uploads[upload.ID]; !ok {
+		return ErrNotFound
+	}
+	upload.UpdatedAt = time.Now()
+	r.uploads[upload.ID] = *upload
+	return nil
+}
+
+// MemorySessions is an in-memory SessionRepository for tests
+type MemorySessions struct {
+	mu       sync.Mutex
+	nextID   uint
+	sessions map[string]models.Session
+	messages []models.ChatMessage
+}
+
+func NewMemorySessions() *MemorySessions {
+	return &MemorySessions{sessions: map[string]models.Session{}}
+}
+
+func (r *MemorySessions) Create(ctx context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sessions[session.ID]; ok {
+		return fmt.Errorf("session %s already exists", session.ID)
+	}
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *MemorySessions) Get(ctx context.Context, id string) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &session, nil
+}
+
+func (r *MemorySessions) Update(ctx context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.sessions[session.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.Status = session.Status
+	stored.LastActiveAt = session.LastActiveAt
+	stored.ExpiresAt = session.ExpiresAt
+	r.sessions[session.ID] = stored
+	return nil
+}
+
+func (r *MemorySessions) AddMessages(ctx context.Context, messages ...models.ChatMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, message := range messages {
+		r.nextID++
+		message.ID = r.nextID
+		message.CreatedAt = now
+		r.messages = append(r.messages, message)
+	}
+	return nil
+}
+
+func (r *MemorySessions) ListMessages(ctx context.Context, sessionID string, opts ListOptions) ([]models.ChatMessage, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Messages are appended in order, so they are already oldest first
+	var matched []models.ChatMessage
+	for _, message := range r.messages {
+		if message.SessionID == sessionID {
+			matched = append(matched, message)
+		}
+	}
+	return page(matched, opts), int64(len(matched)), nil
+}
+
+// sortBy sorts items by fields, breaking ties by id like the GORM
+// repositories do
+func sortBy[T any](items []T, fields []SortField, comparators map[string]func(a, b T) int) error {
+	for _, field := range fields {
+		if _, ok := comparators[field.Column]; !ok {
+			return fmt.Errorf("cannot sort by %q", field.Column)
+		}
+	}
+	fields = append(slices.Clone(fields), SortField{Column: "id"})
+
+	slices.SortStableFunc(items, func(a, b T) int {
+		for _, field := range fields {
+			c := comparators[field.Column](a, b)
+			if field.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return nil
+}
+
+// page returns the slice of items selected by opts, never nil
+func page[T any](items []T, opts ListOptions) []T {
+	start := min(opts.Offset, len(items))
+	end := len(items)
+	if opts.Limit > 0 {
+		end = min(start+opts.Limit, end)
+	}
+	return append([]T{}, items[start:end]...)
+}
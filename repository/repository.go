@@ -0,0 +1,114 @@
+// Package repository hides how products, resumes, uploads and chat sessions
+// are stored. Handlers depend on the interfaces declared here; the GORM
+// implementations back the server and the in-memory ones back unit tests.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-server/models"
+)
+
+// ErrNotFound is returned when the requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// SortField orders a listing by one column
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// ListOptions selects one page of a listing. Sort columns must be among
+// the ones the repository documents as sortable.
+type ListOptions struct {
+	Offset int
+	Limit  int
+	Sort   []SortField
+}
+
+// TimeRange bounds a timestamp column; nil ends are open. After is
+// inclusive and Before exclusive.
+type TimeRange struct {
+	After  *time.Time
+	Before *time.Time
+}
+
+func (r TimeRange) contains(t time.Time) bool {
+	return (r.After == nil || !t.Before(*r.After)) && (r.Before == nil || t.Before(*r.Before))
+}
+
+// ProductFilter narrows a product listing; zero values match everything
+type ProductFilter struct {
+	SellerID *uint
+	// Title matches case-insensitively anywhere in the title
+	Title   string
+	Created TimeRange
+	Updated TimeRange
+}
+
+// ProductRepository stores products. Listings can be sorted by id, title,
+// seller_id, created_at and updated_at.
+type ProductRepository interface {
+	List(ctx context.Context, filter ProductFilter, opts ListOptions) ([]models.Product, int64, error)
+	Get(ctx context.Context, id uint) (*models.Product, error)
+	Create(ctx context.Context, product *models.Product) error
+	Update(ctx context.Context, product *models.Product) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// ResumeFilter narrows a resume listing; zero values match everything
+type ResumeFilter struct {
+	UserID  string
+	Created TimeRange
+	// SchemaStatus matches resumes whose metadata did or did not fit the
+	// typed schema
+	SchemaStatus string
+	// Metadata maps top-level metadata keys to values that must all be
+	// equal to, or contained in, the key's value. Values that are valid
+	// JSON are compared as such, anything else as a string.
+	Metadata map[string][]string
+	// IncludeRawText fills in RawText, which is left empty otherwise
+	IncludeRawText bool
+}
+
+// ResumeRepository stores resumes and their history. Creating or updating
+// a resume normalizes its metadata into the typed schema and records its
+// text and metadata as the next version, along with change. Listings can be
+// sorted by id, user_id, created_at and updated_at.
+type ResumeRepository interface {
+	List(ctx context.Context, filter ResumeFilter, opts ListOptions) ([]models.ResumeSummary, int64, error)
+	Get(ctx context.Context, id uint) (*models.Resume, error)
+	// Latest returns the most recently created resume
+	Latest(ctx context.Context) (*models.Resume, error)
+	Create(ctx context.Context, resume *models.Resume, change models.ResumeChange) error
+	Update(ctx context.Context, resume *models.Resume, change models.ResumeChange) error
+	// Delete removes a resume together with its versions
+	Delete(ctx context.Context, id uint) error
+	// Versions lists the versions of a resume, oldest first
+	Versions(ctx context.Context, resumeID uint, opts ListOptions) ([]models.ResumeVersionSummary, int64, error)
+	// Version returns one version of a resume
+	Version(ctx context.Context, resumeID uint, version int) (*models.ResumeVersion, error)
+}
+
+// UploadRepository stores the upload intents of resume files
+type UploadRepository interface {
+	Create(ctx context.Context, upload *models.ResumeUpload) error
+	Get(ctx context.Context, id uint) (*models.ResumeUpload, error)
+	// GetByKey returns the latest upload to the storage key
+	GetByKey(ctx context.Context, key string) (*models.ResumeUpload, error)
+	Update(ctx context.Context, upload *models.ResumeUpload) error
+}
+
+// SessionRepository stores chat sessions and their messages
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	Get(ctx context.Context, id string) (*models.Session, error)
+	// Update saves the status and activity timestamps of a session
+	Update(ctx context.Context, session *models.Session) error
+	// AddMessages stores chat turns atomically
+	AddMessages(ctx context.Context, messages ...models.ChatMessage) error
+	// ListMessages returns a session's messages, oldest first
+	ListMessages(ctx context.Context, sessionID string, opts ListOptions) ([]models.ChatMessage, int64, error)
+}
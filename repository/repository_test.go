@@ -0,0 +1,393 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go-server/models"
+	"go-server/repository"
+	"go-server/testdb"
+)
+
+// Every test runs against both the GORM repositories on a disposable
+// SQLite database and the in-memory fakes, keeping the two in agreement.
+
+type implementation struct {
+	name     string
+	products func(t *testing.T) repository.ProductRepository
+	resumes  func(t *testing.T) (repository.ResumeRepository, bool)
+	sessions func(t *testing.T) repository.SessionRepository
+	uploads  func(t *testing.T) repository.UploadRepository
+}
+
+var implementations = []implementation{
+	{
+		name:     "gorm",
+		products: func(t *testing.T) repository.ProductRepository { return repository.NewGormProducts(testdb.Open(t)) },
+		resumes: func(t *testing.T) (repository.ResumeRepository, bool) {
+			db := testdb.Open(t)
+			return repository.NewGormResumes(db), !testdb.IsSQLite(db)
+		},
+		sessions: func(t *testing.T) repository.SessionRepository { return repository.NewGormSessions(testdb.Open(t)) },
+		uploads:  func(t *testing.T) repository.UploadRepository { return repository.NewGormUploads(testdb.Open(t)) },
+	},
+	{
+		name:     "memory",
+		products: func(t *testing.T) repository.ProductRepository { return repository.NewMemoryProducts() },
+		resumes: func(t *testing.T) (repository.ResumeRepository, bool) {
+			return repository.NewMemoryResumes(), true
+		},
+		sessions: func(t *testing.T) repository.SessionRepository { return repository.NewMemorySessions() },
+		uploads:  func(t *testing.T) repository.UploadRepository { return repository.NewMemoryUploads() },
+	},
+}
+
+func TestProductRepository(t *testing.T) {
+	ctx := context.Background()
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			repo := impl.products(t)
+			seller1, seller2 := uint(1), uint(2)
+			for _, p := range []models.Product{
+				{SellerID: seller1, Title: "Blue Mug"},
+				{SellerID: seller1, Title: "100% Cotton Shirt"},
+				{SellerID: seller2, Title: "Red mug"},
+			} {
+				p := p
+				if err := repo.Create(ctx, &p); err != nil {
+					t.Fatalf("Create(%q): %v", p.Title, err)
+				}
+				if p.ID == 0 || p.CreatedAt.IsZero() {
+					t.Fatalf("Create(%q) did not fill in ID and timestamps: %+v", p.Title, p)
+				}
+			}
+
+			t.Run("filters", func(t *testing.T) {
+				cases := []struct {
+					name   string
+					filter repository.ProductFilter
+					want   []string
+				}{
+					{"all", repository.ProductFilter{}, []string{"Blue Mug", "100% Cotton Shirt", "Red mug"}},
+					{"title ignores case", repository.ProductFilter{Title: "MUG"}, []string{"Blue Mug", "Red mug"}},
+					{"title escapes wildcards", repository.ProductFilter{Title: "0%"}, []string{"100% Cotton Shirt"}},
+					{"underscore is literal", repository.ProductFilter{Title: "_"}, nil},
+					{"seller", repository.ProductFilter{SellerID: &seller2}, []string{"Red mug"}},
+					{"created in the future", repository.ProductFilter{Created: repository.TimeRange{After: ptr(time.Now().Add(time.Hour))}}, nil},
+				}
+				for _, tc := range cases {
+					products, total, err := repo.List(ctx, tc.filter, repository.ListOptions{})
+					if err != nil {
+						t.Fatalf("%s: List: %v", tc.name, err)
+					}
+					if got := productTitles(products); !slices.Equal(got, tc.want) || total != int64(len(tc.want)) {
+						t.Errorf("%s: got %q (total %d), want %q", tc.name, got, total, tc.want)
+					}
+				}
+			})
+
+			t.Run("sort and page", func(t *testing.T) {
+				opts := repository.ListOptions{
+					Offset: 1,
+					Limit:  1,
+					Sort:   []repository.SortField{{Column: "seller_id"}, {Column: "title", Desc: true}},
+				}
+				products, total, err := repo.List(ctx, repository.ProductFilter{}, opts)
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if got := productTitles(products); !slices.Equal(got, []string{"100% Cotton Shirt"}) || total != 3 {
+					t.Errorf("got %q (total %d), want the second product of seller 1 by descending title", got, total)
+				}
+
+				if _, _, err := repo.List(ctx, repository.ProductFilter{}, repository.ListOptions{Sort: []repository.SortField{{Column: "description"}}}); err == nil {
+					t.Error("sorting by an unlisted column succeeded")
+				}
+			})
+
+			t.Run("update and delete", func(t *testing.T) {
+				product, err := repo.Get(ctx, 1)
+				if err != nil {
+					t.Fatalf("Get: %v", err)
+				}
+				product.Title = "Green Mug"
+				if err := repo.Update(ctx, product); err != nil {
+					t.Fatalf("Update: %v", err)
+				}
+				if product, err = repo.Get(ctx, 1); err != nil || product.Title != "Green Mug" {
+					t.Fatalf("Get after update = %+v, %v", product, err)
+				}
+
+				if err := repo.Delete(ctx, 1); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+				if _, err := repo.Get(ctx, 1); !errors.Is(err, repository.ErrNotFound) {
+					t.Errorf("Get after delete: err = %v, want ErrNotFound", err)
+				}
+				if err := repo.Delete(ctx, 1); !errors.Is(err, repository.ErrNotFound) {
+					t.Errorf("second Delete: err = %v, want ErrNotFound", err)
+				}
+			})
+		})
+	}
+}
+
+func TestResumeRepository(t *testing.T) {
+	ctx := context.Background()
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			repo, metadataFilters := impl.resumes(t)
+			if _, err := repo.Latest(ctx); !errors.Is(err, repository.ErrNotFound) {
+				t.Fatalf("Latest on an empty repository: err = %v, want ErrNotFound", err)
+			}
+
+			for _, r := range []models.Resume{
+				{UserID: "alice", RawText: "Go developer", Metadata: models.JSONB{"skills": []interface{}{"go", "sql"}, "years": 5.0}},
+				{UserID: "bob", RawText: "Designer", Metadata: models.JSONB{"name": "Bob", "skills": []interface{}{"figma"}, "years": 2.0}},
+				{UserID: "alice", RawText: "Go and Rust developer", Metadata: models.JSONB{"skills": []interface{}{"go", "rust"}}},
+			} {
+				r := r
+				if err := repo.Create(ctx, &r, models.ResumeChange{Action: models.ResumeCreated}); err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+			}
+
+			latest, err := repo.Latest(ctx)
+			if err != nil || latest.ID != 3 {
+				t.Fatalf("Latest = %+v, %v; want resume 3", latest, err)
+			}
+			if skills, _ := latest.Metadata["skills"].([]interface{}); len(skills) != 2 {
+				t.Errorf("metadata did not round-trip: %#v", latest.Metadata)
+			}
+
+			summaries, total, err := repo.List(ctx, repository.ResumeFilter{UserID: "alice"}, repository.ListOptions{Sort: []repository.SortField{{Column: "id", Desc: true}}})
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if got := resumeIDs(summaries); !slices.Equal(got, []uint{3, 1}) || total != 2 {
+				t.Errorf("List by user = %v (total %d), want [3 1]", got, total)
+			}
+			if summaries[0].RawText != "" {
+				t.Error("List filled in RawText without IncludeRawText")
+			}
+
+			summaries, _, err = repo.List(ctx, repository.ResumeFilter{SchemaStatus: models.SchemaValid}, repository.ListOptions{})
+			if err != nil || len(summaries) != 1 || summaries[0].UserID != "bob" || summaries[0].SchemaStatus != models.SchemaValid {
+				t.Errorf("List of valid resumes = %+v, %v; want bob's, the only one with a name", summaries, err)
+			}
+
+			summaries, _, err = repo.List(ctx, repository.ResumeFilter{UserID: "bob", IncludeRawText: true}, repository.ListOptions{})
+			if err != nil || len(summaries) != 1 || summaries[0].RawText != "Designer" {
+				t.Errorf("List with IncludeRawText = %+v, %v", summaries, err)
+			}
+
+			if metadataFilters {
+				cases := []struct {
+					metadata map[string][]string
+					want     []uint
+				}{
+					{map[string][]string{"skills": {"go"}}, []uint{1, 3}},
+					{map[string][]string{"skills": {"go", "rust"}}, []uint{3}},
+					{map[string][]string{"years": {"2"}}, []uint{2}},
+					{map[string][]string{"missing": {"x"}}, nil},
+				}
+				for _, tc := range cases {
+					summaries, _, err := repo.List(ctx, repository.ResumeFilter{Metadata: tc.metadata}, repository.ListOptions{})
+					if err != nil {
+						t.Fatalf("List(%v): %v", tc.metadata, err)
+					}
+					if got := resumeIDs(summaries); !slices.Equal(got, tc.want) {
+						t.Errorf("List(%v) = %v, want %v", tc.metadata, got, tc.want)
+					}
+				}
+			}
+
+			if err := repo.Delete(ctx, 2); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := repo.Get(ctx, 2); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("Get after delete: err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestResumeVersions(t *testing.T) {
+	ctx := context.Background()
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			repo, _ := impl.resumes(t)
+			keyID := uint(7)
+			resume := models.Resume{UserID: "alice", RawText: "Go developer", Metadata: models.JSONB{"skills": []interface{}{"go"}}}
+			if err := repo.Create(ctx, &resume, models.ResumeChange{Action: models.ResumeCreated, APIKeyID: &keyID, ChangedBy: "recruiting"}); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			other := models.Resume{UserID: "bob", RawText: "Designer"}
+			if err := repo.Create(ctx, &other, models.ResumeChange{Action: models.ResumeCreated}); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			resume.RawText = "Senior Go developer"
+			resume.Metadata = models.JSONB{"skills": []interface{}{"go", "sql"}}
+			if err := repo.Update(ctx, &resume, models.ResumeChange{Action: models.ResumeUpdated, ChangedBy: "candidate"}); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+
+			versions, total, err := repo.Versions(ctx, resume.ID, repository.ListOptions{})
+			if err != nil || total != 2 || len(versions) != 2 {
+				t.Fatalf("Versions = %+v (total %d), %v; want 2", versions, total, err)
+			}
+			if versions[0].Version != 1 || versions[0].Action != models.ResumeCreated || versions[0].APIKeyID == nil || *versions[0].APIKeyID != keyID || versions[0].ChangedBy != "recruiting" {
+				t.Errorf("first version = %+v", versions[0])
+			}
+			if versions[1].Version != 2 || versions[1].Action != models.ResumeUpdated || versions[1].ChangedBy != "candidate" {
+				t.Errorf("second version = %+v", versions[1])
+			}
+			if versions, total, _ := repo.Versions(ctx, resume.ID, repository.ListOptions{Offset: 1, Limit: 1}); len(versions) != 1 || versions[0].Version != 2 || total != 2 {
+				t.Errorf("second page of versions = %+v (total %d)", versions, total)
+			}
+
+			first, err := repo.Version(ctx, resume.ID, 1)
+			if err != nil || first.RawText != "Go developer" {
+				t.Fatalf("Version 1 = %+v, %v", first, err)
+			}
+			if skills, _ := first.Metadata["skills"].([]interface{}); len(skills) != 1 {
+				t.Errorf("version 1 metadata = %#v", first.Metadata)
+			}
+			if _, err := repo.Version(ctx, resume.ID, 3); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("Version 3: err = %v, want ErrNotFound", err)
+			}
+			if v, err := repo.Version(ctx, other.ID, 1); err != nil || v.RawText != "Designer" {
+				t.Errorf("Version 1 of another resume = %+v, %v", v, err)
+			}
+
+			if err := repo.Delete(ctx, resume.ID); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if versions, total, err := repo.Versions(ctx, resume.ID, repository.ListOptions{}); err != nil || len(versions) != 0 || total != 0 {
+				t.Errorf("Versions after delete = %+v (total %d), %v", versions, total, err)
+			}
+		})
+	}
+}
+
+func TestSessionRepository(t *testing.T) {
+	ctx := context.Background()
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			repo := impl.sessions(t)
+			now := time.Now()
+			session := models.Session{
+				ID:           "session-1",
+				OwnerID:      "alice",
+				Status:       models.SessionStatusActive,
+				LastActiveAt: now,
+				ExpiresAt:    now.Add(time.Hour),
+			}
+			if err := repo.Create(ctx, &session); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if _, err := repo.Get(ctx, "session-2"); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("Get of a missing session: err = %v, want ErrNotFound", err)
+			}
+
+			session.Status = models.SessionStatusExpired
+			if err := repo.Update(ctx, &session); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			stored, err := repo.Get(ctx, "session-1")
+			if err != nil || stored.Status != models.SessionStatusExpired || stored.OwnerID != "alice" {
+				t.Fatalf("Get after update = %+v, %v", stored, err)
+			}
+
+			if err := repo.AddMessages(ctx,
+				models.ChatMessage{SessionID: "session-1", Role: models.ChatRoleUser, Content: "hello"},
+				models.ChatMessage{SessionID: "session-1", Role: models.ChatRoleAssistant, Content: "hi"},
+			); err != nil {
+				t.Fatalf("AddMessages: %v", err)
+			}
+			if err := repo.AddMessages(ctx, models.ChatMessage{SessionID: "session-1", Role: models.ChatRoleUser, Content: "bye"}); err != nil {
+				t.Fatalf("AddMessages: %v", err)
+			}
+
+			messages, total, err := repo.ListMessages(ctx, "session-1", repository.ListOptions{Offset: 1, Limit: 5})
+			if err != nil {
+				t.Fatalf("ListMessages: %v", err)
+			}
+			var contents []string
+			for _, m := range messages {
+				contents = append(contents, m.Content)
+			}
+			if !slices.Equal(contents, []string{"hi", "bye"}) || total != 3 {
+				t.Errorf("ListMessages = %q (total %d), want [hi bye] of 3", contents, total)
+			}
+
+			if messages, total, err := repo.ListMessages(ctx, "session-2", repository.ListOptions{}); err != nil || len(messages) != 0 || total != 0 {
+				t.Errorf("ListMessages of an unknown session = %v, %d, %v", messages, total, err)
+			}
+		})
+	}
+}
+
+func TestUploadRepository(t *testing.T) {
+	ctx := context.Background()
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			repo := impl.uploads(t)
+			upload := models.ResumeUpload{
+				Key:       "resumes/cv.pdf",
+				FileName:  "cv.pdf",
+				OwnerID:   "alice",
+				Status:    models.UploadPending,
+				ExpiresAt: time.Now().Add(time.Minute),
+			}
+			if err := repo.Create(ctx, &upload); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if _, err := repo.Get(ctx, upload.ID+1); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("Get of a missing upload: err = %v, want ErrNotFound", err)
+			}
+			if byKey, err := repo.GetByKey(ctx, upload.Key); err != nil || byKey.ID != upload.ID {
+				t.Errorf("GetByKey = %+v, %v", byKey, err)
+			}
+			if _, err := repo.GetByKey(ctx, "resumes/other.pdf"); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("GetByKey of a missing key: err = %v, want ErrNotFound", err)
+			}
+
+			now := time.Now()
+			upload.Status = models.UploadCompleted
+			upload.Size = 42
+			upload.CompletedAt = &now
+			if err := repo.Update(ctx, &upload); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			stored, err := repo.Get(ctx, upload.ID)
+			if err != nil || stored.Status != models.UploadCompleted || stored.Size != 42 || stored.CompletedAt == nil {
+				t.Errorf("Get after update = %+v, %v", stored, err)
+			}
+		})
+	}
+}
+
+func productTitles(products []models.Product) []string {
+	var titles []string
+	for _, p := range products {
+		titles = append(titles, p.Title)
+	}
+	return titles
+}
+
+func resumeIDs(resumes []models.ResumeSummary) []uint {
+	var ids []uint
+	for _, r := range resumes {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"go-server/handlers"
+	"go-server/logging"
+	"go-server/metrics"
+	"go-server/middleware"
+	"go-server/models"
+	"go-server/services"
+	"go-server/tracing"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// maxResumeBodyBytes bounds the body of resume writes, which keeps stored
+// texts, and so the diffs between their versions, at resume size
+const maxResumeBodyBytes = 1 << 20
+
+// routes holds everything the router dispatches to
+type routes struct {
+	serviceName string
+	apiKeys     middleware.APIKeyAuthenticator
+	health      *handlers.HealthHandler
+	// status answers the legacy /health endpoint
+	status   gin.HandlerFunc
+	products *handlers.ProductHandler
+	resumes  *handlers.ResumeHandler
+	sessions *handlers.SessionHandler
+	// storage answers signed storage URLs; nil unless storage is local
+	storage *handlers.StorageHandler
+}
+
+// newRouter builds the engine with the shared middleware chain and every
+// route of the server
+func newRouter(rt routes) *gin.Engine {
+	r := gin.New()
+	r.Use(tracing.Middleware(rt.serviceName), middleware.RequestID(), middleware.RequestLogger(), metrics.Middleware())
+
+	// Render handler errors and panics as application/problem+json
+	r.Use(middleware.Recovery(), middleware.Problems())
+	r.NoRoute(middleware.NoRoute)
+
+	// Configure CORS
+	r.Use(cors.New(cors.Config{
+		AllowOrigins:     []string{"*"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", logging.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", logging.RequestIDHeader},
+		AllowCredentials: true,
+	}))
+
+	// Health check endpoints
+	r.GET("/livez", rt.health.Livez)
+	r.GET("/readyz", rt.health.Readyz)
+	r.GET("/health", rt.status)
+
+	// Prometheus metrics
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Product routes
+	v1 := r.Group("/api/v1")
+	{
+		products := v1.Group("/products")
+		{
+			products.GET("", rt.products.GetProducts)
+			products.GET("/:id", rt.products.GetProduct)
+
+			productWrite := products.Group("", middleware.APIKeyAuth(rt.apiKeys, models.ScopeProductWrite))
+			productWrite.POST("", rt.products.CreateProduct)
+			productWrite.PUT("/:id", rt.products.UpdateProduct)
+			productWrite.DELETE("/:id", rt.products.DeleteProduct)
+		}
+
+		// Resume routes with API key authentication
+		resumes := v1.Group("/resume")
+		{
+			resumeRead := resumes.Group("", middleware.APIKeyAuth(rt.apiKeys, models.ScopeResumeRead))
+			resumeRead.GET("", rt.resumes.ListResumes)
+			resumeRead.GET("/latest", rt.resumes.LatestResume)
+			resumeRead.GET("/jobs/:id", rt.resumes.GetParseJob)
+			resumeRead.GET("/:id", rt.resumes.GetResume)
+			resumeRead.GET("/:id/file", rt.resumes.GetResumeFile)
+			resumeRead.GET("/:id/versions", rt.resumes.ListVersions)
+			resumeRead.GET("/:id/versions/:version", rt.resumes.GetVersion)
+			resumeRead.GET("/:id/diff", rt.resumes.DiffVersions)
+
+			resumeWrite := resumes.Group("", middleware.APIKeyAuth(rt.apiKeys, models.ScopeResumeWrite), middleware.BodyLimit(maxResumeBodyBytes))
+			resumeWrite.GET("/getSignedUrl", rt.resumes.GetSignedURL)
+			resumeWrite.POST("/uploads/:id/complete", rt.resumes.CompleteUpload)
+			resumeWrite.POST("", rt.resumes.CreateResume)
+			resumeWrite.PUT("/:id", rt.resumes.UpdateResume)
+			resumeWrite.POST("/:id/versions/:version/restore", rt.resumes.RestoreVersion)
+			resumeWrite.DELETE("/:id", rt.resumes.DeleteResume)
+		}
+
+		// Session routes with API key authentication
+		sessions := v1.Group("/session")
+		sessions.Use(middleware.APIKeyAuth(rt.apiKeys, models.ScopeSessionChat))
+		{
+			sessions.GET("/init", rt.sessions.InitSession)
+			sessions.POST("/chat", rt.sessions.ChatSession)
+			sessions.POST("/chat/stream", rt.sessions.ChatSessionStream)
+			sessions.GET("/:id/messages", rt.sessions.ListMessages)
+		}
+	}
+
+	// Signed upload and download URLs of the local storage backend
+	if rt.storage != nil {
+		r.PUT(services.LocalStoragePath+"*key", rt.storage.UploadObject)
+		r.GET(services.LocalStoragePath+"*key", rt.storage.DownloadObject)
+	}
+
+	// Swagger documentation
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	return r
+}
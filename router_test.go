@@ -0,0 +1,581 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server/config"
+	"go-server/diff"
+	"go-server/handlers"
+	"go-server/middleware"
+	"go-server/models"
+	"go-server/repository"
+	"go-server/services"
+	"go-server/testdb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeParser returns a fixed parse of every file
+type fakeParser struct{}
+
+func (fakeParser) Parse(ctx context.Context, fileName string) (*services.ParsedResume, error) {
+	return &services.ParsedResume{
+		TextContent: "Parsed " + fileName,
+		SessionID:   "user-" + strings.TrimSuffix(fileName, ".pdf"),
+		Metadata:    models.JSONB{"skills": []interface{}{"go"}},
+	}, nil
+}
+
+var uploadSettings = handlers.UploadSettings{
+	UploadURLExpiry:   time.Minute,
+	DownloadURLExpiry: time.Minute,
+	MaxBytes:          1024,
+}
+
+// testServer runs the full router against the GORM repositories on a
+// disposable SQLite database and a fake chat upstream
+type testServer struct {
+	*httptest.Server
+	apiKey  string
+	storage *services.LocalStorage
+	uploads repository.UploadRepository
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	chatUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(services.ChatAnswer{SessionID: body["session_id"], Answer: "You asked: " + body["message"]})
+	}))
+	t.Cleanup(chatUpstream.Close)
+
+	db := testdb.Open(t)
+	apiKeys := services.NewAPIKeyStore(db)
+	apiKey, err := apiKeys.Create(context.Background(), &models.APIKey{
+		Name:    "test",
+		OwnerID: "tests",
+		Scopes:  strings.Join([]string{models.ScopeResumeRead, models.ScopeResumeWrite, models.ScopeSessionChat}, " "),
+	})
+	if err != nil {
+		t.Fatalf("create API key: %v", err)
+	}
+
+	// An empty base URL makes the signed URLs relative to the test server
+	storage, err := services.NewLocalStorage(t.TempDir(), "", "secret")
+	if err != nil {
+		t.Fatalf("create storage: %v", err)
+	}
+
+	resumes := repository.NewGormResumes(db)
+	uploads := repository.NewGormUploads(db)
+	chat := config.Default().Chat
+	chat.URL = chatUpstream.URL
+	r := newRouter(routes{
+		serviceName: "bonga-test",
+		apiKeys:     apiKeys,
+		health:      handlers.NewHealthHandler(),
+		status:      func(c *gin.Context) { c.Status(http.StatusOK) },
+		products:    &handlers.ProductHandler{Products: repository.NewGormProducts(db)},
+		resumes:     handlers.NewResumeHandler(resumes, uploads, storage, uploadSettings, fakeParser{}, services.NewParseQueue(db, resumes, fakeParser{})),
+		sessions:    handlers.NewSessionHandler(repository.NewGormSessions(db), resumes, services.NewChatClient(chat), time.Hour),
+		storage:     handlers.NewStorageHandler(storage),
+	})
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return &testServer{Server: srv, apiKey: apiKey, storage: storage, uploads: uploads}
+}
+
+// uploaded records completed uploads of keys by the test key's owner, so
+// that resumes can be parsed from them
+func (s *testServer) uploaded(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		now := time.Now()
+		upload := models.ResumeUpload{
+			Key:         key,
+			FileName:    key,
+			OwnerID:     "tests",
+			Status:      models.UploadCompleted,
+			ExpiresAt:   now,
+			CompletedAt: &now,
+		}
+		if err := s.uploads.Create(context.Background(), &upload); err != nil {
+			t.Fatalf("record upload: %v", err)
+		}
+	}
+}
+
+// do sends a request, with the API key when authenticated is set, and decodes a
+// JSON response into v after checking its status
+func (s *testServer) do(t *testing.T, method, path string, authenticated bool, body interface{}, want int, v interface{}) http.Header {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		encoded, _ := json.Marshal(body)
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authenticated {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != want {
+		t.Fatalf("%s %s: status = %d, want %d; body: %s", method, path, resp.StatusCode, want, raw)
+	}
+	if v != nil {
+		if err := json.Unmarshal(raw, v); err != nil {
+			t.Fatalf("%s %s: decode %s: %v", method, path, raw, err)
+		}
+	}
+	return resp.Header
+}
+
+func TestRouterResumeLifecycle(t *testing.T) {
+	s := newTestServer(t)
+
+	s.do(t, http.MethodGet, "/livez", false, nil, http.StatusOK, nil)
+	s.do(t, http.MethodGet, "/readyz", false, nil, http.StatusOK, nil)
+
+	var problem middleware.Problem
+	s.do(t, http.MethodGet, "/api/v1/resume", false, nil, http.StatusUnauthorized, &problem)
+	if problem.Code != middleware.CodeUnauthorized || problem.RequestID == "" {
+		t.Errorf("problem = %+v, want unauthorized with a request ID", problem)
+	}
+	s.do(t, http.MethodGet, "/api/v1/resume/latest", true, nil, http.StatusNotFound, nil)
+
+	s.uploaded(t, "alice.pdf", "bob.pdf", "carol.pdf")
+	s.do(t, http.MethodPost, "/api/v1/resume", true, models.ParseResumeRequest{FileName: "alice.pdf"}, http.StatusCreated, nil)
+	s.do(t, http.MethodPost, "/api/v1/resume", true, models.ParseResumeRequest{FileName: "bob.pdf"}, http.StatusCreated, nil)
+
+	var latest models.Resume
+	s.do(t, http.MethodGet, "/api/v1/resume/latest", true, nil, http.StatusOK, &latest)
+	if latest.UserID != "user-bob" || latest.RawText != "Parsed bob.pdf" {
+		t.Errorf("latest = %+v", latest)
+	}
+
+	var page handlers.ResumeListResponse
+	s.do(t, http.MethodGet, "/api/v1/resume?user_id=user-alice&include_raw_text=true", true, nil, http.StatusOK, &page)
+	if page.Total != 1 || page.Items[0].RawText != "Parsed alice.pdf" {
+		t.Errorf("page = %+v", page)
+	}
+
+	latest.RawText = "Edited"
+	s.do(t, http.MethodPut, "/api/v1/resume/2", true, latest, http.StatusOK, nil)
+	var resume models.Resume
+	s.do(t, http.MethodGet, "/api/v1/resume/2", true, nil, http.StatusOK, &resume)
+	if resume.RawText != "Edited" {
+		t.Errorf("raw text after update = %q", resume.RawText)
+	}
+
+	s.do(t, http.MethodDelete, "/api/v1/resume/2", true, nil, http.StatusNoContent, nil)
+	s.do(t, http.MethodGet, "/api/v1/resume/2", true, nil, http.StatusNotFound, nil)
+
+	var job models.ParseJob
+	header := s.do(t, http.MethodPost, "/api/v1/resume?async=true", true, models.ParseResumeRequest{FileName: "carol.pdf"}, http.StatusAccepted, &job)
+	if job.Status != models.ParseJobPending || header.Get("Location") == "" {
+		t.Errorf("job = %+v, Location = %q", job, header.Get("Location"))
+	}
+	s.do(t, http.MethodGet, header.Get("Location"), true, nil, http.StatusOK, nil)
+}
+
+func TestRouterResumeVersions(t *testing.T) {
+	s := newTestServer(t)
+	s.uploaded(t, "alice.pdf")
+	s.do(t, http.MethodPost, "/api/v1/resume", true, models.ParseResumeRequest{FileName: "alice.pdf"}, http.StatusCreated, nil)
+
+	var resume models.Resume
+	s.do(t, http.MethodGet, "/api/v1/resume/1", true, nil, http.StatusOK, &resume)
+	resume.RawText = "Edited"
+	resume.Metadata = models.JSONB{"skills": []interface{}{"go", "sql"}}
+	s.do(t, http.MethodPut, "/api/v1/resume/1", true, resume, http.StatusOK, nil)
+
+	var restored models.Resume
+	s.do(t, http.MethodPost, "/api/v1/resume/1/versions/1/restore", true, nil, http.StatusOK, &restored)
+	if restored.RawText != "Parsed alice.pdf" || restored.SourceKey != "alice.pdf" {
+		t.Errorf("restored = %+v", restored)
+	}
+
+	var versions handlers.ResumeVersionListResponse
+	s.do(t, http.MethodGet, "/api/v1/resume/1/versions", true, nil, http.StatusOK, &versions)
+	if versions.Total != 3 {
+		t.Fatalf("versions = %+v, want 3", versions)
+	}
+	for i, action := range []string{models.ResumeCreated, models.ResumeUpdated, models.ResumeRestored} {
+		v := versions.Items[i]
+		if v.Version != i+1 || v.Action != action || v.ChangedBy != "tests" || v.APIKeyID == nil {
+			t.Errorf("version %d = %+v, want %s by the test key", i+1, v, action)
+		}
+	}
+	if from := versions.Items[2].RestoredFrom; from == nil || *from != 1 {
+		t.Errorf("restored_from = %v, want 1", from)
+	}
+
+	var version models.ResumeVersion
+	s.do(t, http.MethodGet, "/api/v1/resume/1/versions/2", true, nil, http.StatusOK, &version)
+	if version.RawText != "Edited" || len(version.Metadata["skills"].([]interface{})) != 2 {
+		t.Errorf("version 2 = %+v", version)
+	}
+
+	var changes handlers.ResumeDiffResponse
+	s.do(t, http.MethodGet, "/api/v1/resume/1/diff?from=1&to=2", true, nil, http.StatusOK, &changes)
+	if changes.RawText != "--- version 1\n+++ version 2\n@@ -1 +1 @@\n-Parsed alice.pdf\n+Edited\n" {
+		t.Errorf("raw text diff = %q", changes.RawText)
+	}
+	if len(changes.Metadata) != 1 || changes.Metadata[0].Path != "skills[1]" || changes.Metadata[0].Op != diff.Added {
+		t.Errorf("metadata diff = %+v", changes.Metadata)
+	}
+	s.do(t, http.MethodGet, "/api/v1/resume/1/diff?from=1&to=3", true, nil, http.StatusOK, &changes)
+	if changes.RawText != "" || len(changes.Metadata) != 0 {
+		t.Errorf("diff of a restored version = %+v, want none", changes)
+	}
+	s.do(t, http.MethodGet, "/api/v1/resume/1/diff?from=1", true, nil, http.StatusBadRequest, nil)
+	s.do(t, http.MethodGet, "/api/v1/resume/1/diff?from=1&to=5", true, nil, http.StatusNotFound, nil)
+
+	s.do(t, http.MethodGet, "/api/v1/resume/1/versions/4", true, nil, http.StatusNotFound, nil)
+	s.do(t, http.MethodGet, "/api/v1/resume/1/versions/0", true, nil, http.StatusBadRequest, nil)
+	s.do(t, http.MethodPost, "/api/v1/resume/1/versions/9/restore", true, nil, http.StatusNotFound, nil)
+	s.do(t, http.MethodGet, "/api/v1/resume/2/versions", true, nil, http.StatusNotFound, nil)
+}
+
+func TestRouterUpdateResumeReplacesContent(t *testing.T) {
+	s := newTestServer(t)
+	s.uploaded(t, "alice.pdf")
+	s.do(t, http.MethodPost, "/api/v1/resume", true, models.ParseResumeRequest{FileName: "alice.pdf"}, http.StatusCreated, nil)
+
+	// Only raw_text and metadata are taken from the body
+	body := map[string]interface{}{
+		"raw_text":   "Edited",
+		"metadata":   map[string]interface{}{"name": "Alice"},
+		"user_id":    "mallory",
+		"created_at": "2001-01-01T00:00:00Z",
+	}
+	var resume models.Resume
+	s.do(t, http.MethodPut, "/api/v1/resume/1", true, body, http.StatusOK, &resume)
+	if _, ok := resume.Metadata["skills"]; ok || resume.Metadata["name"] != "Alice" {
+		t.Errorf("metadata = %v, want the skills key removed", resume.Metadata)
+	}
+	if resume.UserID != "user-alice" || resume.CreatedAt.Year() == 2001 || resume.SchemaStatus != models.SchemaValid {
+		t.Errorf("resume = %+v, want server-set fields kept", resume)
+	}
+
+	var version models.ResumeVersion
+	s.do(t, http.MethodGet, "/api/v1/resume/1/versions/2", true, nil, http.StatusOK, &version)
+	if len(version.Metadata) != 1 || version.Metadata["name"] != "Alice" {
+		t.Errorf("version 2 metadata = %v", version.Metadata)
+	}
+	var changes handlers.ResumeDiffResponse
+	s.do(t, http.MethodGet, "/api/v1/resume/1/diff?from=1&to=2", true, nil, http.StatusOK, &changes)
+	want := []diff.Change{
+		{Path: "name", Op: diff.Added, To: "Alice"},
+		{Path: "skills", Op: diff.Removed, From: []interface{}{"go"}},
+	}
+	if !reflect.DeepEqual(changes.Metadata, want) {
+		t.Errorf("metadata diff = %+v, want %+v", changes.Metadata, want)
+	}
+
+	s.do(t, http.MethodPut, "/api/v1/resume/1", true, map[string]interface{}{"metadata": map[string]interface{}{}}, http.StatusBadRequest, nil)
+
+	var problem middleware.Problem
+	s.do(t, http.MethodPut, "/api/v1/resume/1", true, models.UpdateResumeRequest{RawText: strings.Repeat("x", maxResumeBodyBytes)}, http.StatusRequestEntityTooLarge, &problem)
+	if problem.Code != middleware.CodeRequestTooLarge {
+		t.Errorf("problem = %+v", problem)
+	}
+}
+
+func TestRouterResumeSchema(t *testing.T) {
+	s := newTestServer(t)
+	s.uploaded(t, "alice.pdf", "bob.pdf")
+	s.do(t, http.MethodPost, "/api/v1/resume", true, models.ParseResumeRequest{FileName: "alice.pdf"}, http.StatusCreated, nil)
+	s.do(t, http.MethodPost, "/api/v1/resume", true, models.ParseResumeRequest{FileName: "bob.pdf"}, http.StatusCreated, nil)
+
+	// The fake parser returns no name
+	var resume models.Resume
+	s.do(t, http.MethodGet, "/api/v1/resume/1", true, nil, http.StatusOK, &resume)
+	if resume.SchemaStatus != models.SchemaInvalid || len(resume.SchemaErrors) != 1 || resume.SchemaErrors[0].Field != "contact.name" {
+		t.Errorf("parsed resume: status %q, errors %+v", resume.SchemaStatus, resume.SchemaErrors)
+	}
+	if resume.Profile == nil || len(resume.Profile.Skills) != 1 {
+		t.Errorf("parsed resume profile = %+v", resume.Profile)
+	}
+
+	resume.Metadata = models.JSONB{
+		"name":       "Alice",
+		"skills":     "go, sql",
+		"experience": []interface{}{map[string]interface{}{"title": "Engineer", "company": "Acme", "start": "2020"}},
+	}
+	resume.SchemaStatus = models.SchemaInvalid
+	s.do(t, http.MethodPut, "/api/v1/resume/1", true, resume, http.StatusOK, nil)
+	var edited models.Resume
+	s.do(t, http.MethodGet, "/api/v1/resume/1", true, nil, http.StatusOK, &edited)
+	if edited.SchemaStatus != models.SchemaValid || len(edited.SchemaErrors) != 0 {
+		t.Errorf("edited resume: status %q, errors %+v", edited.SchemaStatus, edited.SchemaErrors)
+	}
+	if p := edited.Profile; p == nil || p.Contact.Name != "Alice" || len(p.Skills) != 2 || len(p.Experience) != 1 || p.Experience[0].StartDate != "2020" {
+		t.Errorf("edited resume profile = %+v", edited.Profile)
+	}
+
+	var page handlers.ResumeListResponse
+	s.do(t, http.MethodGet, "/api/v1/resume?schema_status=invalid", true, nil, http.StatusOK, &page)
+	if page.Total != 1 || page.Items[0].ID != 2 || page.Items[0].SchemaStatus != models.SchemaInvalid {
+		t.Errorf("invalid resumes = %+v", page)
+	}
+	s.do(t, http.MethodGet, "/api/v1/resume?schema_status=unknown", true, nil, http.StatusBadRequest, nil)
+}
+
+func TestRouterSessionChat(t *testing.T) {
+	s := newTestServer(t)
+	s.uploaded(t, "alice.pdf")
+	s.do(t, http.MethodPost, "/api/v1/resume", true, models.ParseResumeRequest{FileName: "alice.pdf"}, http.StatusCreated, nil)
+
+	var session handlers.InitSessionResponse
+	s.do(t, http.MethodGet, "/api/v1/session/init?resumeId=1", true, nil, http.StatusCreated, &session)
+
+	var answer handlers.SessionChatResponse
+	s.do(t, http.MethodPost, "/api/v1/session/chat", true, handlers.SessionChatRequest{
+		SessionID: session.SessionID,
+		Question:  "Where did you work?",
+	}, http.StatusOK, &answer)
+	if answer.Answer != "You asked: Where did you work?" {
+		t.Errorf("answer = %q", answer.Answer)
+	}
+
+	var messages handlers.ChatMessageListResponse
+	s.do(t, http.MethodGet, "/api/v1/session/"+session.SessionID+"/messages", true, nil, http.StatusOK, &messages)
+	if messages.Total != 2 {
+		t.Errorf("messages = %+v, want the question and the answer", messages)
+	}
+}
+
+// upload sends body to a presigned upload URL with the given headers and
+// returns the response status
+func (s *testServer) upload(t *testing.T, signedURL string, headers map[string]string, body string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPut, s.URL+signedURL, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	req.ContentLength = int64(len(body))
+
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+const pdf = "%PDF-1.7 resume"
+
+func TestRouterUploadAndDownload(t *testing.T) {
+	s := newTestServer(t)
+
+	var signed handlers.UploadURLResponse
+	s.do(t, http.MethodGet, fmt.Sprintf("/api/v1/resume/getSignedUrl?filename=My%%20CV.PDF&size=%d", len(pdf)), true, nil, http.StatusOK, &signed)
+	if !strings.HasPrefix(signed.Key, "resumes/tests/") || !strings.HasSuffix(signed.Key, ".pdf") || strings.Contains(signed.Key, "CV") {
+		t.Errorf("key = %q, want a generated key under the owner's prefix", signed.Key)
+	}
+	if signed.Headers["Content-Type"] != "application/pdf" {
+		t.Errorf("headers = %v", signed.Headers)
+	}
+
+	var problem middleware.Problem
+	complete := fmt.Sprintf("/api/v1/resume/uploads/%d/complete", signed.UploadID)
+	s.do(t, http.MethodPost, complete, true, nil, http.StatusConflict, &problem)
+	if problem.Code != middleware.CodeUploadIncomplete {
+		t.Errorf("completing before the upload: problem = %+v", problem)
+	}
+
+	// The signature pins the key, method, content type and length
+	wrongType := map[string]string{"Content-Type": "text/html"}
+	cases := []struct {
+		name    string
+		url     string
+		headers map[string]string
+		body    string
+	}{
+		{"other key", strings.Replace(signed.URL, ".pdf", ".docx", 1), signed.Headers, pdf},
+		{"other content type", signed.URL, wrongType, pdf},
+		{"other length", signed.URL, signed.Headers, pdf + "!"},
+	}
+	for _, tc := range cases {
+		if status := s.upload(t, tc.url, tc.headers, tc.body); status != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want 403", tc.name, status)
+		}
+	}
+	s.do(t, http.MethodGet, signed.URL, false, nil, http.StatusForbidden, nil)
+
+	if status := s.upload(t, signed.URL, signed.Headers, pdf); status != http.StatusOK {
+		t.Fatalf("upload status = %d", status)
+	}
+
+	var upload models.ResumeUpload
+	s.do(t, http.MethodPost, complete, true, nil, http.StatusOK, &upload)
+	if upload.Status != models.UploadCompleted || upload.Size != int64(len(pdf)) || upload.FileName != "My CV.PDF" || upload.OwnerID != "tests" {
+		t.Errorf("completed upload = %+v", upload)
+	}
+
+	s.do(t, http.MethodPost, "/api/v1/resume", true, models.ParseResumeRequest{UploadID: signed.UploadID}, http.StatusCreated, nil)
+	var file handlers.FileURLResponse
+	s.do(t, http.MethodGet, "/api/v1/resume/1/file", true, nil, http.StatusOK, &file)
+	if file.Key != signed.Key || file.Size != int64(len(pdf)) || file.ContentType != "application/pdf" {
+		t.Errorf("file = %+v", file)
+	}
+
+	resp, err := s.Client().Get(s.URL + file.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	downloaded, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(downloaded) != pdf || resp.Header.Get("Content-Type") != "application/pdf" {
+		t.Errorf("download: status %d, %s: %q", resp.StatusCode, resp.Header.Get("Content-Type"), downloaded)
+	}
+}
+
+func TestRouterRejectsMismatchedUpload(t *testing.T) {
+	s := newTestServer(t)
+
+	var signed handlers.UploadURLResponse
+	s.do(t, http.MethodGet, fmt.Sprintf("/api/v1/resume/getSignedUrl?filename=cv.pdf&size=%d", len(pdf)), true, nil, http.StatusOK, &signed)
+
+	// Storage that does not enforce the policy accepted another file type
+	if _, err := s.storage.Put(context.Background(), signed.Key, "text/html", strings.NewReader(pdf)); err != nil {
+		t.Fatal(err)
+	}
+
+	var problem middleware.Problem
+	complete := fmt.Sprintf("/api/v1/resume/uploads/%d/complete", signed.UploadID)
+	s.do(t, http.MethodPost, complete, true, nil, http.StatusUnprocessableEntity, &problem)
+	if problem.Code != middleware.CodeUploadRejected {
+		t.Errorf("problem = %+v", problem)
+	}
+	if _, err := s.storage.Head(context.Background(), signed.Key); !errors.Is(err, services.ErrObjectNotFound) {
+		t.Errorf("rejected file was kept: %v", err)
+	}
+	s.do(t, http.MethodPost, complete, true, nil, http.StatusUnprocessableEntity, nil)
+}
+
+func TestRouterSignedURLRestrictions(t *testing.T) {
+	s := newTestServer(t)
+
+	cases := []struct {
+		query string
+		field string
+	}{
+		{"size=10", "filename"},
+		{"filename=cv.exe&size=10", "filename"},
+		{"filename=../cv.pdf&size=10", "filename"},
+		{"filename=" + strings.Repeat("a", 300) + ".pdf&size=10", "filename"},
+		{"filename=cv.pdf", "size"},
+		{"filename=cv.pdf&size=0", "size"},
+		{"filename=cv.pdf&size=1025", "size"},
+	}
+	for _, tc := range cases {
+		var problem middleware.Problem
+		s.do(t, http.MethodGet, "/api/v1/resume/getSignedUrl?"+tc.query, true, nil, http.StatusBadRequest, &problem)
+		if len(problem.Errors) != 1 || problem.Errors[0].Field != tc.field {
+			t.Errorf("%s: errors = %+v, want one for %s", tc.query, problem.Errors, tc.field)
+		}
+	}
+
+	// Two uploads of the same file name get distinct keys
+	var first, second handlers.UploadURLResponse
+	s.do(t, http.MethodGet, "/api/v1/resume/getSignedUrl?filename=cv.docx&size=10", true, nil, http.StatusOK, &first)
+	s.do(t, http.MethodGet, "/api/v1/resume/getSignedUrl?filename=cv.docx&size=10", true, nil, http.StatusOK, &second)
+	if first.Key == second.Key {
+		t.Errorf("both uploads got key %q", first.Key)
+	}
+}
+
+func TestRouterResumeFileMissing(t *testing.T) {
+	s := newTestServer(t)
+	s.uploaded(t, "never-uploaded.pdf")
+	s.do(t, http.MethodPost, "/api/v1/resume", true, models.ParseResumeRequest{FileName: "never-uploaded.pdf"}, http.StatusCreated, nil)
+
+	var problem middleware.Problem
+	s.do(t, http.MethodGet, "/api/v1/resume/1/file", true, nil, http.StatusNotFound, &problem)
+	if problem.Detail != "The source file of the resume no longer exists" {
+		t.Errorf("problem = %+v", problem)
+	}
+	s.do(t, http.MethodGet, "/api/v1/resume/2/file", true, nil, http.StatusNotFound, nil)
+	s.do(t, http.MethodPost, "/api/v1/resume/uploads/9/complete", true, nil, http.StatusNotFound, nil)
+}
+
+func TestRouterResumeRequiresOwnUpload(t *testing.T) {
+	s := newTestServer(t)
+
+	var signed handlers.UploadURLResponse
+	s.do(t, http.MethodGet, fmt.Sprintf("/api/v1/resume/getSignedUrl?filename=cv.pdf&size=%d", len(pdf)), true, nil, http.StatusOK, &signed)
+
+	foreign := models.ResumeUpload{Key: "resumes/other/cv.pdf", FileName: "cv.pdf", OwnerID: "other", Status: models.UploadCompleted, ExpiresAt: time.Now()}
+	if err := s.uploads.Create(context.Background(), &foreign); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		request models.ParseResumeRequest
+		status  int
+	}{
+		{"nothing named", models.ParseResumeRequest{}, http.StatusBadRequest},
+		{"unknown key", models.ParseResumeRequest{FileName: "resumes/tests/unknown.pdf"}, http.StatusBadRequest},
+		{"unknown upload", models.ParseResumeRequest{UploadID: 99}, http.StatusBadRequest},
+		{"pending upload", models.ParseResumeRequest{FileName: signed.Key}, http.StatusBadRequest},
+		{"another owner's key", models.ParseResumeRequest{FileName: foreign.Key}, http.StatusForbidden},
+		{"another owner's upload", models.ParseResumeRequest{UploadID: foreign.ID}, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		for _, path := range []string{"/api/v1/resume", "/api/v1/resume?async=true"} {
+			t.Run(tc.name+" "+path, func(t *testing.T) {
+				s.do(t, http.MethodPost, path, true, tc.request, tc.status, nil)
+			})
+		}
+	}
+	s.do(t, http.MethodGet, "/api/v1/resume/latest", true, nil, http.StatusNotFound, nil)
+}
+
+func TestRouterProductsRequireScope(t *testing.T) {
+	s := newTestServer(t)
+
+	var problem middleware.Problem
+	s.do(t, http.MethodPost, "/api/v1/products", true, models.ProductRequest{Title: "Mug"}, http.StatusForbidden, &problem)
+	if problem.Code != middleware.CodeForbidden {
+		t.Errorf("problem = %+v", problem)
+	}
+
+	var page handlers.ProductListResponse
+	s.do(t, http.MethodGet, "/api/v1/products", false, nil, http.StatusOK, &page)
+	if page.Total != 0 || page.Items == nil {
+		t.Errorf("page = %+v, want an empty list", page)
+	}
+	s.do(t, http.MethodGet, "/api/v1/nope", false, nil, http.StatusNotFound, nil)
+}
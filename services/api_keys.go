@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-server/models"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyPrefix marks the keys issued by this server
+const apiKeyPrefix = "bonga"
+
+// lastUsedResolution limits how often last_used_at is written for a key
+const lastUsedResolution = time.Minute
+
+var (
+	// ErrInvalidAPIKey is returned for unknown, malformed or expired keys
+	ErrInvalidAPIKey = errors.New("invalid or missing API key")
+)
+
+// APIKeyStore issues and verifies database-backed API keys
+type APIKeyStore struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyStore(db *gorm.DB) *APIKeyStore {
+	return &APIKeyStore{db: db}
+}
+
+// Create generates a secret for key, stores the key and returns the secret
+// in plain text. The plain key cannot be recovered later.
+func (s *APIKeyStore) Create(ctx context.Context, key *models.APIKey) (string, error) {
+	prefix, err := randomHex(4)
+	if err != nil {
+		return "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	plain := fmt.Sprintf("%s_%s_%s", apiKeyPrefix, prefix, secret)
+
+	key.Prefix = prefix
+	key.KeyHash = hashAPIKey(plain)
+	if key.Role == "" {
+		key.Role = models.RoleUser
+	}
+	if err := s.db.WithContext(ctx).Create(key).Error; err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// Count returns the number of keys that are not expired
+func (s *APIKeyStore) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+// Authenticate looks up the key by its prefix, compares hashes in constant
+// time and records when the key was last used
+func (s *APIKeyStore) Authenticate(ctx context.Context, plain string) (*models.APIKey, error) {
+	parts := strings.Split(plain, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var key models.APIKey
+	if err := s.db.WithContext(ctx).First(&key, "prefix = ?", parts[1]).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(plain)), []byte(key.KeyHash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.Expired(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		key.LastUsedAt = &now
+		s.db.WithContext(ctx).Model(&key).UpdateColumn("last_used_at", now)
+	}
+	return &key, nil
+}
+
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go-server/config"
+)
+
+// ChatAnswer is the upstream's reply to a chat message
+type ChatAnswer struct {
+	Answer     string `json:"answer"`
+	SessionID  string `json:"session_id"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"-"`
+}
+
+// ChatStatusError is returned when the chat service answers with a non-2xx
+// status
+type ChatStatusError struct {
+	StatusCode int
+}
+
+func (e *ChatStatusError) Error() string {
+	return fmt.Sprintf("chat API returned status %d", e.StatusCode)
+}
+
+// ChatClient calls the external chat service. Plain chat calls are bounded
+// by a short deadline, while streaming calls only end with their context.
+// Both share one circuit breaker since they hit the same upstream.
+type ChatClient struct {
+	baseURL string
+	client  *ResilientClient
+	stream  *ResilientClient
+}
+
+func NewChatClient(cfg config.ChatConfig) *ChatClient {
+	breaker := NewCircuitBreaker("chat", cfg.BreakerThreshold, cfg.BreakerOpenFor)
+	return &ChatClient{
+		baseURL: strings.TrimRight(cfg.URL, "/"),
+		client:  NewResilientClient(breaker, ResilientClientConfig{Timeout: cfg.Timeout}),
+		stream:  NewResilientClient(breaker, ResilientClientConfig{}),
+	}
+}
+
+// Ping checks that the chat service is reachable
+func (c *ChatClient) Ping(ctx context.Context) error {
+	return PingHTTP(ctx, c.baseURL)
+}
+
+// Breaker returns the circuit breaker guarding calls to the chat service
+func (c *ChatClient) Breaker() *CircuitBreaker {
+	return c.client.Breaker()
+}
+
+// Ask sends message to the upstream session and decodes the answer. A non-2xx
+// status is reported as a *ChatStatusError. The returned answer carries the
+// upstream status code even when the call fails after reaching upstream.
+func (c *ChatClient) Ask(ctx context.Context, sessionID, message string) (*ChatAnswer, error) {
+	resp, err := c.post(ctx, c.client, "/session/chat", "application/json", sessionID, message)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	answer := ChatAnswer{StatusCode: resp.StatusCode}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, resp.Body)
+		return &answer, &ChatStatusError{StatusCode: resp.StatusCode}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+		return &answer, fmt.Errorf("failed to parse response: %v", err)
+	}
+	return &answer, nil
+}
+
+// Stream sends message to the upstream session and returns the raw
+// Server-Sent Events response. The caller must close the body; cancelling
+// ctx aborts the upstream request.
+func (c *ChatClient) Stream(ctx context.Context, sessionID, message string) (*http.Response, error) {
+	return c.post(ctx, c.stream, "/session/chat/stream", "text/event-stream", sessionID, message)
+}
+
+func (c *ChatClient) post(ctx context.Context, client *ResilientClient, path, accept, sessionID, message string) (*http.Response, error) {
+	body, err := json.Marshal(map[string]string{
+		"session_id": sessionID,
+		"message":    message,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call chat API: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalStoragePath is where the server answers the URLs signed by
+// LocalStorage
+const LocalStoragePath = "/storage/"
+
+var (
+	// ErrInvalidStorageKey is returned for keys that would escape the
+	// storage directory
+	ErrInvalidStorageKey = errors.New("invalid storage key")
+	// ErrInvalidSignature is returned for tampered or expired storage URLs
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+)
+
+// LocalStorage keeps files on disk and signs URLs served by this server, so
+// that uploads work without any cloud service. Objects live under
+// <dir>/objects and their content types under <dir>/meta.
+type LocalStorage struct {
+	dir     string
+	baseURL string
+	secret  []byte
+}
+
+// localObjectMeta is the sidecar file stored next to each object
+type localObjectMeta struct {
+	ContentType string `json:"content_type"`
+}
+
+// NewLocalStorage stores files below dir and signs URLs rooted at baseURL,
+// the externally visible address of this server. Without a secret a random
+// one is generated, so URLs do not survive a restart.
+func NewLocalStorage(dir, baseURL, secret string) (*LocalStorage, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("STORAGE_DIR is not configured")
+	}
+	for _, sub := range []string{"objects", "meta"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o750); err != nil {
+			return nil, fmt.Errorf("unable to create storage directory: %v", err)
+		}
+	}
+
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &LocalStorage{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  key,
+	}, nil
+}
+
+// PresignPut signs the content type and length of policy along with the
+// URL, like S3 does with signed headers
+func (s *LocalStorage) PresignPut(ctx context.Context, key string, policy UploadPolicy) (*PresignedRequest, error) {
+	request := SignedRequest{Method: "PUT", Key: key, ContentType: policy.ContentType, ContentLength: policy.ContentLength}
+	url, err := s.sign(request, policy.Expires)
+	if err != nil {
+		return nil, err
+	}
+	return &PresignedRequest{
+		URL: url,
+		Headers: map[string]string{
+			"Content-Type":   policy.ContentType,
+			"Content-Length": strconv.FormatInt(policy.ContentLength, 10),
+		},
+	}, nil
+}
+
+func (s *LocalStorage) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return s.sign(SignedRequest{Method: "GET", Key: key}, expires)
+}
+
+// SignedRequest is the part of a request to a signed storage URL that the
+// signature covers
+type SignedRequest struct {
+	Method        string
+	Key           string
+	ContentType   string
+	ContentLength int64
+}
+
+// sign returns a URL granting request until expires has elapsed
+func (s *LocalStorage) sign(request SignedRequest, expires time.Duration) (string, error) {
+	if _, err := s.objectPath(request.Key); err != nil {
+		return "", err
+	}
+	deadline := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", deadline)
+	query.Set("signature", s.signature(request, deadline))
+	return s.baseURL + LocalStoragePath + (&url.URL{Path: request.Key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+func (s *LocalStorage) signature(request SignedRequest, deadline string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%d", request.Method, request.Key, deadline, request.ContentType, request.ContentLength)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature and deadline taken from a URL returned by
+// PresignPut or PresignGet against the request made with it
+func (s *LocalStorage) Verify(request SignedRequest, deadline, signature string) error {
+	expires, err := strconv.ParseInt(deadline, 10, 64)
+	if err != nil || !time.Now().Before(time.Unix(expires, 0)) {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signature(request, deadline))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Put stores the contents of r under key, replacing any earlier object. The
+// object only becomes visible once it has been written completely.
+func (s *LocalStorage) Put(ctx context.Context, key, contentType string, r io.Reader) (*ObjectInfo, error) {
+	objectPath, err := s.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0o750); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(objectPath), ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	// The sidecar follows the object, so a failed rename leaves no
+	// description behind for an object that was never stored
+	if err := os.Rename(tmp.Name(), objectPath); err != nil {
+		return nil, err
+	}
+	if err := s.writeMeta(key, localObjectMeta{ContentType: contentType}); err != nil {
+		return nil, err
+	}
+	return s.Head(ctx, key)
+}
+
+// Open returns the contents of key along with its description. The caller
+// must close the file.
+func (s *LocalStorage) Open(ctx context.Context, key string) (*os.File, *ObjectInfo, error) {
+	info, err := s.Head(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	objectPath, _ := s.objectPath(key)
+	file, err := os.Open(objectPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrObjectNotFound
+	}
+	return file, info, err
+}
+
+func (s *LocalStorage) Head(ctx context.Context, key string) (*ObjectInfo, error) {
+	objectPath, err := s.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := os.Stat(objectPath)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && stat.IsDir()) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.describe(key, stat), nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	objectPath, err := s.objectPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(objectPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(s.metaPath(objectPath)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	root := filepath.Join(s.dir, "objects")
+	objects := []ObjectInfo{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		stat, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, *s.describe(key, stat))
+		return nil
+	})
+	return objects, err
+}
+
+// Ping checks that the storage directory is still writable
+func (s *LocalStorage) Ping(ctx context.Context) error {
+	probe, err := os.CreateTemp(filepath.Join(s.dir, "objects"), ".upload-ping-*")
+	if err != nil {
+		return fmt.Errorf("storage directory is not writable: %v", err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+func (s *LocalStorage) describe(key string, stat fs.FileInfo) *ObjectInfo {
+	info := &ObjectInfo{Key: key, Size: stat.Size(), LastModified: stat.ModTime()}
+	objectPath, _ := s.objectPath(key)
+	if content, err := os.ReadFile(s.metaPath(objectPath)); err == nil {
+		var meta localObjectMeta
+		if json.Unmarshal(content, &meta) == nil {
+			info.ContentType = meta.ContentType
+		}
+	}
+	return info
+}
+
+func (s *LocalStorage) writeMeta(key string, meta localObjectMeta) error {
+	objectPath, _ := s.objectPath(key)
+	metaPath := s.metaPath(objectPath)
+	if err := os.MkdirAll(filepath.Dir(metaPath), 0o750); err != nil {
+		return err
+	}
+	content, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	// Replace the sidecar atomically so readers never see a partial one
+	tmp, err := os.CreateTemp(filepath.Dir(metaPath), ".meta-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o640); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), metaPath)
+}
+
+// objectPath maps key to a file below the objects directory, rejecting keys
+// that are empty, absolute or climb out of it
+func (s *LocalStorage) objectPath(key string) (string, error) {
+	if key == "" || path.Clean(key) != key || !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", ErrInvalidStorageKey
+	}
+	return filepath.Join(s.dir, "objects", filepath.FromSlash(key)), nil
+}
+
+// metaPath returns the sidecar file of the object stored at objectPath
+func (s *LocalStorage) metaPath(objectPath string) string {
+	rel, _ := filepath.Rel(filepath.Join(s.dir, "objects"), objectPath)
+	return filepath.Join(s.dir, "meta", rel+".json")
+}
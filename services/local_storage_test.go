@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStorage(t.TempDir(), "http://files.test/", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Head(ctx, "resumes/cv.pdf"); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("Head of a missing key: err = %v, want ErrObjectNotFound", err)
+	}
+
+	info, err := store.Put(ctx, "resumes/cv.pdf", "application/pdf", strings.NewReader("%PDF-1.7"))
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if info.Size != 8 || info.ContentType != "application/pdf" {
+		t.Errorf("Put = %+v", info)
+	}
+	if _, err := store.Put(ctx, "other/a.txt", "text/plain", strings.NewReader("a")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	objects, err := store.List(ctx, "resumes/")
+	if err != nil || len(objects) != 1 || objects[0].Key != "resumes/cv.pdf" {
+		t.Fatalf("List = %+v, %v", objects, err)
+	}
+
+	if err := store.Delete(ctx, "resumes/cv.pdf"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Head(ctx, "resumes/cv.pdf"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Head after delete: err = %v, want ErrObjectNotFound", err)
+	}
+	if err := store.Delete(ctx, "resumes/cv.pdf"); err != nil {
+		t.Errorf("Delete of a missing key: %v", err)
+	}
+	if err := store.Ping(ctx); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+}
+
+func TestLocalStoragePutWritesSidecarLast(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewLocalStorage(dir, "", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Put(ctx, "resumes/cv.pdf", "application/pdf", strings.NewReader("%PDF-1.7")); err != nil {
+		t.Fatal(err)
+	}
+	// The "resumes" directory is in the way, so the final rename fails
+	if _, err := store.Put(ctx, "resumes", "text/plain", strings.NewReader("a")); err == nil {
+		t.Fatal("Put over a directory succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "meta", "resumes.json")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("sidecar of the failed Put: err = %v, want it not written", err)
+	}
+}
+
+func TestLocalStorageRejectsUnsafeKeys(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "http://files.test", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", "/etc/passwd", "../escape", "a/../../escape", "a//b", "a/./b", "dir/"} {
+		if _, err := store.Put(context.Background(), key, "", strings.NewReader("x")); !errors.Is(err, ErrInvalidStorageKey) {
+			t.Errorf("Put(%q): err = %v, want ErrInvalidStorageKey", key, err)
+		}
+		if _, err := store.PresignGet(context.Background(), key, time.Minute); !errors.Is(err, ErrInvalidStorageKey) {
+			t.Errorf("PresignGet(%q): err = %v, want ErrInvalidStorageKey", key, err)
+		}
+	}
+}
+
+func TestLocalStorageSignatures(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "http://files.test", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy := UploadPolicy{ContentType: "application/pdf", ContentLength: 42, Expires: time.Minute}
+	presigned, err := store.PresignPut(context.Background(), "resumes/my cv.pdf", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presigned.Headers["Content-Type"] != "application/pdf" || presigned.Headers["Content-Length"] != "42" {
+		t.Errorf("headers = %v", presigned.Headers)
+	}
+	signed, err := url.Parse(presigned.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Host != "files.test" || signed.Path != LocalStoragePath+"resumes/my cv.pdf" {
+		t.Fatalf("signed URL = %s", presigned.URL)
+	}
+	expires, signature := signed.Query().Get("expires"), signed.Query().Get("signature")
+
+	request := SignedRequest{Method: "PUT", Key: "resumes/my cv.pdf", ContentType: "application/pdf", ContentLength: 42}
+	if err := store.Verify(request, expires, signature); err != nil {
+		t.Errorf("Verify of the signed URL: %v", err)
+	}
+	cases := []struct {
+		name    string
+		change  func(r *SignedRequest)
+		expires string
+	}{
+		{"other method", func(r *SignedRequest) { r.Method = "GET" }, expires},
+		{"other key", func(r *SignedRequest) { r.Key = "resumes/other.pdf" }, expires},
+		{"other content type", func(r *SignedRequest) { r.ContentType = "text/html" }, expires},
+		{"other length", func(r *SignedRequest) { r.ContentLength = 43 }, expires},
+		{"extended expiry", func(r *SignedRequest) {}, expires + "0"},
+		{"expired", func(r *SignedRequest) {}, "1"},
+	}
+	for _, tc := range cases {
+		changed := request
+		tc.change(&changed)
+		if err := store.Verify(changed, tc.expires, signature); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: err = %v, want ErrInvalidSignature", tc.name, err)
+		}
+	}
+
+	other, _ := NewLocalStorage(t.TempDir(), "http://files.test", "another secret")
+	if err := other.Verify(request, expires, signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify with another secret: err = %v, want ErrInvalidSignature", err)
+	}
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go-server/metrics"
+	"go-server/models"
+	"go-server/repository"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// parseQueuePollInterval is how often idle workers look for new jobs
+	parseQueuePollInterval = 2 * time.Second
+	// parseJobStaleAfter is how long a job may stay running before it is
+	// assumed to belong to a crashed worker and handed out again
+	parseJobStaleAfter = 5 * time.Minute
+	// parseJobMaxAttempts bounds how often a stale job is retried; a stale
+	// job that has used them up is marked failed
+	parseJobMaxAttempts = 3
+)
+
+// ParseQueue stores resume parse jobs in the database and processes them
+// with a pool of in-process workers. Jobs are claimed with SKIP LOCKED, so
+// several server instances can share one queue. Parsed resumes are stored
+// through resumes.
+type ParseQueue struct {
+	db      *gorm.DB
+	resumes repository.ResumeRepository
+	parser  ResumeParser
+	wake    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func NewParseQueue(db *gorm.DB, resumes repository.ResumeRepository, parser ResumeParser) *ParseQueue {
+	return &ParseQueue{
+		db:      db,
+		resumes: resumes,
+		parser:  parser,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Enqueue stores a pending job for fileName and wakes an idle worker. The
+// job remembers key, which becomes the author of the parsed resume. The
+// caller must have checked that key may read fileName, which becomes the
+// resume's source file.
+func (q *ParseQueue) Enqueue(ctx context.Context, fileName string, key *models.APIKey) (*models.ParseJob, error) {
+	job := models.ParseJob{
+		FileName: fileName,
+		Status:   models.ParseJobPending,
+	}
+	if key != nil {
+		job.OwnerID = key.OwnerID
+		job.APIKeyID = &key.ID
+	}
+	if err := q.db.WithContext(ctx).Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return &job, nil
+}
+
+// Get returns the job with the given ID if it was queued under key's owner.
+// Admin keys, and a nil key, see every job.
+func (q *ParseQueue) Get(ctx context.Context, id uint, key *models.APIKey) (*models.ParseJob, error) {
+	query := q.db.WithContext(ctx)
+	if key != nil && !key.IsAdmin() {
+		query = query.Where("owner_id = ?", key.OwnerID)
+	}
+	var job models.ParseJob
+	if err := query.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Start launches n workers that run until ctx is cancelled
+func (q *ParseQueue) Start(ctx context.Context, n int) {
+	for i := 0; i < n; i++ {
+		q.wg.Add(1)
+		go q.work(ctx)
+	}
+}
+
+// Wait blocks until every worker has returned
+func (q *ParseQueue) Wait() {
+	q.wg.Wait()
+}
+
+func (q *ParseQueue) work(ctx context.Context) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(parseQueuePollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before going back to sleep
+		for {
+			job, err := q.claim(ctx)
+			if err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) && ctx.Err() == nil {
+					slog.ErrorContext(ctx, "failed to claim parse job", "error", err)
+				}
+				break
+			}
+			q.process(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim atomically moves the oldest pending (or stale running) job to
+// running, after failing stale jobs that have no attempts left
+func (q *ParseQueue) claim(ctx context.Context) (*models.ParseJob, error) {
+	var job models.ParseJob
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		staleBefore := time.Now().Add(-parseJobStaleAfter)
+		err := tx.Model(&models.ParseJob{}).
+			Where("status = ? AND started_at < ? AND attempts >= ?", models.ParseJobRunning, staleBefore, parseJobMaxAttempts).
+			Updates(map[string]interface{}{
+				"status":      models.ParseJobFailed,
+				"error":       fmt.Sprintf("abandoned after %d attempts that did not finish", parseJobMaxAttempts),
+				"finished_at": time.Now(),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND started_at < ? AND attempts < ?)",
+				models.ParseJobPending, models.ParseJobRunning, staleBefore, parseJobMaxAttempts).
+			Order("id").
+			First(&job).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = models.ParseJobRunning
+		job.StartedAt = &now
+		job.Attempts++
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":     job.Status,
+			"started_at": job.StartedAt,
+			"attempts":   job.Attempts,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// process parses the job's file, stores the resume and records the outcome
+func (q *ParseQueue) process(ctx context.Context, job *models.ParseJob) {
+	ctx, span := tracer.Start(ctx, "parse_job.process", trace.WithAttributes(
+		attribute.Int64("parse_job.id", int64(job.ID)),
+		attribute.Int("parse_job.attempt", job.Attempts),
+	))
+	defer span.End()
+
+	updates := map[string]interface{}{}
+
+	resume, err := q.parseAndStore(ctx, job)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		if ctx.Err() != nil {
+			// Shutting down; hand the job back without charging the attempt
+			q.release(job)
+			return
+		}
+		slog.ErrorContext(ctx, "parse job failed", "job_id", job.ID, "attempt", job.Attempts, "error", err)
+		updates["status"] = models.ParseJobFailed
+		updates["error"] = jobError(err)
+	} else {
+		updates["status"] = models.ParseJobSucceeded
+		updates["resume_id"] = resume.ID
+		updates["error"] = ""
+	}
+	updates["finished_at"] = time.Now()
+
+	// Record the outcome even if ctx is cancelled in the meantime
+	if err := q.db.WithContext(context.WithoutCancel(ctx)).Model(job).Updates(updates).Error; err != nil {
+		slog.Error("failed to update parse job", "job_id", job.ID, "error", err)
+	}
+}
+
+// errResumeNotSaved marks a parse whose resume could not be stored
+var errResumeNotSaved = errors.New("failed to save resume")
+
+// jobError returns the message a failed job reports to clients. Like the
+// problems of the synchronous endpoints it names the failure without
+// exposing the underlying error, which is only logged.
+func jobError(err error) string {
+	var open *CircuitOpenError
+	var statusErr *ParserStatusError
+	switch {
+	case errors.As(err, &open):
+		return "The " + open.Name + " service is temporarily unavailable"
+	case errors.Is(err, ErrParserNotConfigured):
+		return "Resume parsing is not configured"
+	case errors.As(err, &statusErr):
+		return fmt.Sprintf("The resume could not be parsed (upstream status %d)", statusErr.StatusCode)
+	case errors.Is(err, errResumeNotSaved):
+		return "The parsed resume could not be saved"
+	default:
+		return "The resume could not be parsed"
+	}
+}
+
+// release returns a job interrupted by shutdown to the queue
+func (q *ParseQueue) release(job *models.ParseJob) {
+	err := q.db.Model(job).Updates(map[string]interface{}{
+		"status":     models.ParseJobPending,
+		"started_at": nil,
+		"attempts":   gorm.Expr("attempts - 1"),
+	}).Error
+	if err != nil {
+		slog.Error("failed to release parse job", "job_id", job.ID, "error", err)
+	}
+}
+
+func (q *ParseQueue) parseAndStore(ctx context.Context, job *models.ParseJob) (*models.Resume, error) {
+	parsed, err := q.parser.Parse(ctx, job.FileName)
+	if err != nil {
+		return nil, err
+	}
+
+	resume := models.Resume{
+		UserID:    parsed.SessionID,
+		OwnerID:   job.OwnerID,
+		RawText:   parsed.TextContent,
+		Metadata:  parsed.Metadata,
+		SourceKey: job.FileName,
+	}
+	change := models.ResumeChange{
+		Action:    models.ResumeCreated,
+		APIKeyID:  job.APIKeyID,
+		ChangedBy: job.OwnerID,
+	}
+	if err := q.resumes.Create(ctx, &resume, change); err != nil {
+		return nil, fmt.Errorf("%w: %v", errResumeNotSaved, err)
+	}
+	metrics.ResumesCreated.Inc()
+	return &resume, nil
+}
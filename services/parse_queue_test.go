@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-server/models"
+	"go-server/repository"
+	"go-server/testdb"
+
+	"gorm.io/gorm"
+)
+
+// blockingParser blocks until the parse is cancelled
+type blockingParser struct{}
+
+func (blockingParser) Parse(ctx context.Context, fileName string) (*ParsedResume, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestParseQueueFailsAbandonedJobs(t *testing.T) {
+	db := testdb.Open(t)
+	q := NewParseQueue(db, repository.NewGormResumes(db), blockingParser{})
+
+	stale := time.Now().Add(-2 * parseJobStaleAfter)
+	abandoned := models.ParseJob{FileName: "a.pdf", Status: models.ParseJobRunning, Attempts: parseJobMaxAttempts, StartedAt: &stale}
+	retried := models.ParseJob{FileName: "b.pdf", Status: models.ParseJobRunning, Attempts: 1, StartedAt: &stale}
+	for _, job := range []*models.ParseJob{&abandoned, &retried} {
+		if err := db.Create(job).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	job, err := q.claim(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ID != retried.ID || job.Attempts != 2 {
+		t.Errorf("claimed %+v, want the job with attempts left", job)
+	}
+
+	stored, err := q.Get(context.Background(), abandoned.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.ParseJobFailed || stored.Error == "" || stored.FinishedAt == nil {
+		t.Errorf("abandoned job = %+v, want it failed", stored)
+	}
+}
+
+func TestParseQueueReleasesJobsOnShutdown(t *testing.T) {
+	db := testdb.Open(t)
+	q := NewParseQueue(db, repository.NewGormResumes(db), blockingParser{})
+
+	if _, err := q.Enqueue(context.Background(), "a.pdf", nil); err != nil {
+		t.Fatal(err)
+	}
+	job, err := q.claim(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q.process(ctx, job)
+
+	stored, err := q.Get(context.Background(), job.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.ParseJobPending || stored.Attempts != 0 || stored.StartedAt != nil {
+		t.Errorf("interrupted job = %+v, want it pending without a charged attempt", stored)
+	}
+}
+
+func TestParseQueueGetIsScopedToOwner(t *testing.T) {
+	db := testdb.Open(t)
+	q := NewParseQueue(db, repository.NewGormResumes(db), blockingParser{})
+
+	alice := &models.APIKey{ID: 1, OwnerID: "alice", Role: models.RoleUser}
+	job, err := q.Enqueue(context.Background(), "a.pdf", alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := q.Get(context.Background(), job.ID, alice); err != nil {
+		t.Errorf("owner lookup: %v", err)
+	}
+	if _, err := q.Get(context.Background(), job.ID, &models.APIKey{ID: 2, OwnerID: "bob", Role: models.RoleUser}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("foreign lookup error = %v, want not found", err)
+	}
+	if _, err := q.Get(context.Background(), job.ID, &models.APIKey{ID: 3, OwnerID: "ops", Role: models.RoleAdmin}); err != nil {
+		t.Errorf("admin lookup: %v", err)
+	}
+}
+
+// failingParser fails every parse with an error naming internal details
+type failingParser struct{ err error }
+
+func (p failingParser) Parse(ctx context.Context, fileName string) (*ParsedResume, error) {
+	return nil, p.err
+}
+
+func TestParseQueueHidesFailureDetails(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{errors.New(`Get "http://parser.internal:8080/resume/parse": dial tcp 10.0.0.7:8080: connection refused`), "The resume could not be parsed"},
+		{fmt.Errorf("failed to call parse API: %w", &ParserStatusError{StatusCode: 500}), "The resume could not be parsed (upstream status 500)"},
+		{fmt.Errorf("failed to call parse API: %w", &CircuitOpenError{Name: "parser", RetryAfter: time.Second}), "The parser service is temporarily unavailable"},
+		{ErrParserNotConfigured, "Resume parsing is not configured"},
+	}
+	for _, tc := range cases {
+		db := testdb.Open(t)
+		q := NewParseQueue(db, repository.NewGormResumes(db), failingParser{tc.err})
+		if _, err := q.Enqueue(context.Background(), "a.pdf", nil); err != nil {
+			t.Fatal(err)
+		}
+		job, err := q.claim(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		q.process(context.Background(), job)
+
+		stored, err := q.Get(context.Background(), job.ID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != models.ParseJobFailed || stored.Error != tc.want {
+			t.Errorf("job failed with %q: status %q, error %q, want %q", tc.err, stored.Status, stored.Error, tc.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-server/config"
+	"go-server/models"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// ErrParserNotConfigured is returned when the parser has no API token
+var ErrParserNotConfigured = errors.New("parse API token not configured")
+
+// ParsedResume is the result of parsing a resume file
+type ParsedResume struct {
+	TextContent string       `json:"text_content"`
+	SessionID   string       `json:"session_id"`
+	Metadata    models.JSONB `json:"metadata"`
+}
+
+// ParserStatusError is returned when the parser answers with a non-2xx status
+type ParserStatusError struct {
+	StatusCode int
+}
+
+func (e *ParserStatusError) Error() string {
+	return fmt.Sprintf("parse API returned status %d", e.StatusCode)
+}
+
+// ResumeParser turns an uploaded resume file into text and metadata
+type ResumeParser interface {
+	Parse(ctx context.Context, fileName string) (*ParsedResume, error)
+}
+
+// HTTPResumeParser calls the external resume parsing service
+type HTTPResumeParser struct {
+	baseURL string
+	token   string
+	client  *ResilientClient
+}
+
+// sharedTransport is reused by every outbound client so that connections to
+// the upstream services are pooled
+var sharedTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   20,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   5 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
+// tracedTransport records a client span for every request sent over the
+// shared pool and propagates the trace context in the traceparent header
+var tracedTransport = otelhttp.NewTransport(sharedTransport,
+	otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return "HTTP " + r.Method + " " + r.URL.Path
+	}),
+)
+
+// NewHTTPClient returns an http.Client backed by the shared connection pool
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: tracedTransport, Timeout: timeout}
+}
+
+// NewHTTPResumeParser creates a parser client for the configured service
+func NewHTTPResumeParser(cfg config.ParserConfig) *HTTPResumeParser {
+	return &HTTPResumeParser{
+		baseURL: strings.TrimRight(cfg.URL, "/"),
+		token:   cfg.Token,
+		client: NewResilientClient(NewCircuitBreaker("parser", cfg.BreakerThreshold, cfg.BreakerOpenFor), ResilientClientConfig{
+			Timeout:     cfg.Timeout,
+			MaxRetries:  cfg.MaxRetries,
+			BaseBackoff: 500 * time.Millisecond,
+		}),
+	}
+}
+
+// Ping checks that the parser service is reachable
+func (p *HTTPResumeParser) Ping(ctx context.Context) error {
+	return PingHTTP(ctx, p.baseURL)
+}
+
+// Breaker returns the circuit breaker guarding calls to the parser
+func (p *HTTPResumeParser) Breaker() *CircuitBreaker {
+	return p.client.Breaker()
+}
+
+// Parse asks the parser service to parse fileName. The request is cancelled
+// together with ctx.
+func (p *HTTPResumeParser) Parse(ctx context.Context, fileName string) (*ParsedResume, error) {
+	if p.token == "" {
+		return nil, ErrParserNotConfigured
+	}
+
+	parseURL := fmt.Sprintf("%s/resume/parse?fileName=%s", p.baseURL, url.QueryEscape(fileName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parseURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call parse API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, resp.Body)
+		return nil, &ParserStatusError{StatusCode: resp.StatusCode}
+	}
+
+	var parsed ParsedResume
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %v", err)
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go-server/logging"
+)
+
+// pingClient is used for health probes only, so that they never count
+// towards a circuit breaker or get retried
+var pingClient = NewHTTPClient(5 * time.Second)
+
+// PingHTTP checks that the service at baseURL answers at all. Any response
+// below 500 counts as reachable.
+func PingHTTP(ctx context.Context, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL, nil)
+	if err != nil {
+		return err
+	}
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
+	resp, err := pingClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-server/logging"
+	"go-server/metrics"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of outbound calls and background jobs. The
+// individual HTTP attempts get client spans from the traced transport.
+var tracer = otel.Tracer("go-server/services")
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// CircuitOpenError is returned without calling upstream while a breaker is open
+type CircuitOpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s is unavailable, retry after %s", e.Name, e.RetryAfter.Round(time.Second))
+}
+
+// BreakerStatus is a point-in-time view of a circuit breaker
+type BreakerStatus struct {
+	Name                string `json:"name" example:"parser"`
+	State               string `json:"state" example:"closed"`
+	ConsecutiveFailures int    `json:"consecutive_failures" example:"0"`
+	RetryAfterSeconds   int    `json:"retry_after_seconds,omitempty" example:"0"`
+}
+
+// CircuitBreaker stops calls to an upstream after a run of consecutive
+// failures. Once openFor has elapsed a single trial call is let through; its
+// outcome closes the breaker again or re-opens it.
+type CircuitBreaker struct {
+	name      string
+	threshold int
+	openFor   time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func NewCircuitBreaker(name string, threshold int, openFor time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:      name,
+		threshold: threshold,
+		openFor:   openFor,
+		state:     BreakerClosed,
+	}
+}
+
+// Allow reports whether a call may proceed
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if wait := b.openFor - time.Since(b.openedAt); wait > 0 {
+			return &CircuitOpenError{Name: b.name, RetryAfter: wait}
+		}
+		b.state = BreakerHalfOpen
+		b.trial = true
+		return nil
+	case BreakerHalfOpen:
+		if b.trial {
+			return &CircuitOpenError{Name: b.name, RetryAfter: time.Second}
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// Success records a successful call
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.trial = false
+}
+
+// Failure records a failed call, opening the breaker once the threshold is
+// reached or when a half-open trial fails
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// abandon releases a half-open trial without recording an outcome
+func (b *CircuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+// Status returns the current state of the breaker
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		Name:                b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+	}
+	if b.state == BreakerOpen {
+		if wait := b.openFor - time.Since(b.openedAt); wait > 0 {
+			status.RetryAfterSeconds = int(wait.Round(time.Second) / time.Second)
+		}
+	}
+	return status
+}
+
+// ResilientClientConfig tunes a ResilientClient
+type ResilientClientConfig struct {
+	// Timeout bounds a single attempt, including reading the body. Zero
+	// leaves the deadline to the request context.
+	Timeout time.Duration
+	// MaxRetries is the number of extra attempts for idempotent requests
+	MaxRetries int
+	// BaseBackoff is the upper bound of the first retry delay; it doubles
+	// with every attempt and is fully jittered
+	BaseBackoff time.Duration
+}
+
+// ResilientClient wraps the shared HTTP client with per-call deadlines,
+// bounded retries for idempotent requests and a circuit breaker
+type ResilientClient struct {
+	client  *http.Client
+	breaker *CircuitBreaker
+	cfg     ResilientClientConfig
+}
+
+func NewResilientClient(breaker *CircuitBreaker, cfg ResilientClientConfig) *ResilientClient {
+	return &ResilientClient{
+		client:  NewHTTPClient(0),
+		breaker: breaker,
+		cfg:     cfg,
+	}
+}
+
+// Breaker returns the circuit breaker guarding this client
+func (c *ResilientClient) Breaker() *CircuitBreaker {
+	return c.breaker
+}
+
+// Do sends req, retrying idempotent requests on network errors and 502,
+// 503 and 504 responses. A *CircuitOpenError is returned without calling
+// upstream while the breaker is open.
+func (c *ResilientClient) Do(req *http.Request) (*http.Response, error) {
+	if id := logging.RequestID(req.Context()); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
+
+	ctx, span := tracer.Start(req.Context(), c.breaker.name+" "+req.Method+" "+req.URL.Path,
+		trace.WithAttributes(attribute.String("upstream", c.breaker.name)))
+	defer span.End()
+	req = req.WithContext(ctx)
+
+	resp, attempts, err := c.do(req)
+	span.SetAttributes(attribute.Int("upstream.attempts", attempts))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, fmt.Sprintf("upstream returned status %d", resp.StatusCode))
+	}
+	return resp, err
+}
+
+// do runs the retry loop of Do and reports how many attempts it made
+func (c *ResilientClient) do(req *http.Request) (*http.Response, int, error) {
+	attempts := 1
+	if isIdempotent(req) {
+		attempts += c.cfg.MaxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(req.Context(), c.backoff(attempt)); err != nil {
+				return nil, attempt, lastErr
+			}
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, attempt, err
+				}
+				req.Body = body
+			}
+		}
+
+		if err := c.breaker.Allow(); err != nil {
+			return nil, attempt, err
+		}
+
+		resp, err := c.attempt(req)
+		if err != nil {
+			// A cancelled caller says nothing about the upstream's health
+			if req.Context().Err() != nil {
+				c.breaker.abandon()
+				return nil, attempt + 1, err
+			}
+			c.breaker.Failure()
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			c.breaker.Failure()
+		} else {
+			c.breaker.Success()
+		}
+
+		if retryableStatus(resp.StatusCode) && attempt < attempts-1 {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			lastErr = fmt.Errorf("upstream returned status %d", resp.StatusCode)
+			continue
+		}
+		return resp, attempt + 1, nil
+	}
+	return nil, attempts, lastErr
+}
+
+func (c *ResilientClient) attempt(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+	if c.cfg.Timeout <= 0 {
+		resp, err := c.client.Do(req)
+		observeAttempt(c.breaker.name, started, resp, err)
+		return resp, err
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), c.cfg.Timeout)
+	resp, err := c.client.Do(req.WithContext(ctx))
+	observeAttempt(c.breaker.name, started, resp, err)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// Keep the deadline running until the caller has read the body
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+func (c *ResilientClient) backoff(attempt int) time.Duration {
+	ceiling := c.cfg.BaseBackoff << (attempt - 1)
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+// observeAttempt records the latency until the response headers arrived;
+// 5xx responses count as errors
+func observeAttempt(upstream string, started time.Time, resp *http.Response, err error) {
+	if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+		err = fmt.Errorf("upstream returned status %d", resp.StatusCode)
+	}
+	metrics.ObserveUpstream(upstream, started, err)
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	}
+	return false
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RetryAfterSeconds formats the delay of a CircuitOpenError for a
+// Retry-After header, rounding up to a whole second
+func RetryAfterSeconds(err *CircuitOpenError) string {
+	seconds := int((err.RetryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
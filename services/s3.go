@@ -2,54 +2,228 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
 	"time"
 
+	"go-server/logging"
+	"go-server/metrics"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// S3Config selects the bucket of an S3Service. Endpoint and UsePathStyle
+// point the client at S3-compatible servers such as MinIO.
+type S3Config struct {
+	Bucket       string
+	Region       string
+	Endpoint     string
+	UsePathStyle bool
+}
+
+// S3Service stores resume files in an S3 bucket. Credentials come from the
+// AWS default chain.
 type S3Service struct {
-	client *s3.Client
-	bucket string
+	client  *s3.Client
+	presign *s3.PresignClient
+	bucket  string
 }
 
-func NewS3Service() (*S3Service, error) {
-	// Load AWS configuration
-	cfg, err := config.LoadDefaultConfig(context.TODO())
-	if err != nil {
-		return nil, fmt.Errorf("unable to load SDK config: %v", err)
+func NewS3Service(cfg S3Config) (*S3Service, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("AWS_S3_BUCKET is not configured")
 	}
 
-	// Get bucket name from environment variable
-	bucket := os.Getenv("AWS_S3_BUCKET")
-	if bucket == "" {
-		return nil, fmt.Errorf("AWS_S3_BUCKET environment variable is not set")
+	// Load AWS configuration, letting an explicit region take precedence
+	var opts []func(*config.LoadOptions) error
+	if cfg.Region != "" {
+		opts = append(opts, config.WithRegion(cfg.Region))
+	}
+	awsCfg, err := config.LoadDefaultConfig(context.TODO(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load SDK config: %v", err)
 	}
 
 	// Create S3 client
-	client := s3.NewFromConfig(cfg)
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle
+	})
 
 	return &S3Service{
-		client: client,
-		bucket: bucket,
+		client:  client,
+		presign: s3.NewPresignClient(client),
+		bucket:  cfg.Bucket,
 	}, nil
 }
 
-func (s *S3Service) GetPresignedURL(key string) (string, error) {
-	presignClient := s3.NewPresignClient(s.client)
+// observe starts a span for an S3 operation and returns a function that
+// ends it, recording err and the call's latency
+func (s *S3Service) observe(ctx context.Context, operation, key string) (context.Context, func(err error)) {
+	attrs := []attribute.KeyValue{attribute.String("s3.bucket", s.bucket)}
+	if key != "" {
+		attrs = append(attrs, attribute.String("s3.key", key))
+	}
+	ctx, span := tracer.Start(ctx, "s3 "+operation, trace.WithAttributes(attrs...))
+	started := time.Now()
+	return ctx, func(err error) {
+		metrics.ObserveUpstream("s3", started, err)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// PresignPut signs the content type and length of policy into the URL, so
+// S3 rejects uploads that differ from it
+func (s *S3Service) PresignPut(ctx context.Context, key string, policy UploadPolicy) (presigned *PresignedRequest, err error) {
+	ctx, done := s.observe(ctx, "PresignPutObject", key)
+	defer func() { done(err) }()
 
-	// Create presigned URL with 1-minute expiration
-	presignedURL, err := presignClient.PresignPutObject(context.TODO(), &s3.PutObjectInput{
+	request, err := s.presign.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(policy.ContentType),
+		ContentLength: aws.Int64(policy.ContentLength),
+	}, s3.WithPresignExpires(policy.Expires))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate presigned URL: %v", err)
+	}
+
+	presigned = &PresignedRequest{URL: request.URL, Headers: map[string]string{}}
+	for name := range request.SignedHeader {
+		// The client sets Host from the URL
+		if name != "Host" {
+			presigned.Headers[name] = request.SignedHeader.Get(name)
+		}
+	}
+	return presigned, nil
+}
+
+func (s *S3Service) PresignGet(ctx context.Context, key string, expires time.Duration) (url string, err error) {
+	ctx, done := s.observe(ctx, "PresignGetObject", key)
+	defer func() { done(err) }()
+
+	request, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
-	}, s3.WithPresignExpires(time.Minute))
-
+	}, s3.WithPresignExpires(expires))
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned URL: %v", err)
 	}
+	return request.URL, nil
+}
+
+func (s *S3Service) Head(ctx context.Context, key string) (info *ObjectInfo, err error) {
+	ctx, done := s.observe(ctx, "HeadObject", key)
+	defer func() {
+		// A missing object is an answer, not a failure of S3
+		if errors.Is(err, ErrObjectNotFound) {
+			done(nil)
+		} else {
+			done(err)
+		}
+	}()
+
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}, withRequestIDHeader)
+	if err != nil {
+		var notFound *types.NotFound
+		var apiErr smithy.APIError
+		if errors.As(err, &notFound) || (errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchKey") {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to head object %s: %v", key, err)
+	}
+	return &ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		ContentType:  aws.ToString(out.ContentType),
+		LastModified: aws.ToTime(out.LastModified),
+	}, nil
+}
+
+func (s *S3Service) Delete(ctx context.Context, key string) (err error) {
+	ctx, done := s.observe(ctx, "DeleteObject", key)
+	defer func() { done(err) }()
+
+	_, err = s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}, withRequestIDHeader)
+	if err != nil {
+		return fmt.Errorf("failed to delete object %s: %v", key, err)
+	}
+	return nil
+}
+
+func (s *S3Service) List(ctx context.Context, prefix string) (objects []ObjectInfo, err error) {
+	ctx, done := s.observe(ctx, "ListObjectsV2", "")
+	defer func() { done(err) }()
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+	objects = []ObjectInfo{}
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx, withRequestIDHeader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects under %s: %v", prefix, err)
+		}
+		for _, object := range page.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          aws.ToString(object.Key),
+				Size:         aws.ToInt64(object.Size),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// Ping checks that the bucket exists and is reachable with the configured
+// credentials
+func (s *S3Service) Ping(ctx context.Context) (err error) {
+	ctx, done := s.observe(ctx, "HeadBucket", "")
+	defer func() { done(err) }()
+
+	_, err = s.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(s.bucket),
+	}, withRequestIDHeader)
+	if err != nil {
+		return fmt.Errorf("bucket %s is not reachable: %v", s.bucket, err)
+	}
+	return nil
+}
 
-	return presignedURL.URL, nil
-} 
\ No newline at end of file
+// withRequestIDHeader forwards the request ID of the call's context to S3.
+// It is only used for direct calls: on presigned URLs the header would
+// become part of the signature.
+func withRequestIDHeader(o *s3.Options) {
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Build.Add(middleware.BuildMiddlewareFunc("RequestIDHeader",
+			func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+				if req, ok := in.Request.(*smithyhttp.Request); ok {
+					if id := logging.RequestID(ctx); id != "" {
+						req.Header.Set(logging.RequestIDHeader, id)
+					}
+				}
+				return next.HandleBuild(ctx, in)
+			}), middleware.After)
+	})
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrObjectNotFound is returned when a storage key does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string    `json:"key" example:"resumes/cv.pdf"`
+	Size         int64     `json:"size" example:"48213"`
+	ContentType  string    `json:"content_type,omitempty" example:"application/pdf"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// UploadPolicy restricts what a presigned upload URL accepts. The client
+// must send exactly ContentType and ContentLength bytes.
+type UploadPolicy struct {
+	ContentType   string
+	ContentLength int64
+	Expires       time.Duration
+}
+
+// PresignedRequest is a presigned URL and the headers the client must send
+// with it for the signature to match
+type PresignedRequest struct {
+	URL     string            `json:"url" example:"https://bucket.s3.amazonaws.com/resumes/team/4f1c.pdf?X-Amz-Signature=..."`
+	Headers map[string]string `json:"headers"`
+}
+
+// Storage keeps uploaded resume files. Clients never send file contents
+// through the API: they upload and download with presigned URLs that are
+// valid for a limited time.
+type Storage interface {
+	// PresignPut returns a request that uploads key's contents with an HTTP
+	// PUT, restricted by policy
+	PresignPut(ctx context.Context, key string, policy UploadPolicy) (*PresignedRequest, error)
+	// PresignGet returns a URL from which key can be downloaded
+	PresignGet(ctx context.Context, key string, expires time.Duration) (string, error)
+	// Head describes key, or returns ErrObjectNotFound
+	Head(ctx context.Context, key string) (*ObjectInfo, error)
+	// Delete removes key; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// List describes every object whose key starts with prefix
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// Ping checks that the storage is usable
+	Ping(ctx context.Context) error
+}
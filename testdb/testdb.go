@@ -0,0 +1,58 @@
+// Package testdb opens disposable databases for tests. It links the SQLite
+// driver and must therefore only be imported from _test.go files.
+package testdb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go-server/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Open returns a fresh SQLite database in the test's temporary directory
+// with every model migrated. The versioned migrations target PostgreSQL,
+// so the schema comes from the models instead; the postgres-tagged test in
+// migrations/ checks that the two agree.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_foreign_keys=on&_busy_timeout=5000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	if err := db.AutoMigrate(Models()...); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// Models lists every table of the schema
+func Models() []interface{} {
+	return []interface{}{
+		&models.Product{},
+		&models.Resume{},
+		&models.ResumeVersion{},
+		&models.ResumeUpload{},
+		&models.Session{},
+		&models.ChatMessage{},
+		&models.ParseJob{},
+		&models.APIKey{},
+	}
+}
+
+// IsSQLite reports whether db is backed by SQLite, whose dialect lacks the
+// PostgreSQL-only features some queries use
+func IsSQLite(db *gorm.DB) bool {
+	return db.Dialector.Name() == "sqlite"
+}
@@ -0,0 +1,92 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "tracing:span"
+
+// GormPlugin creates a client span for every query GORM runs within a
+// traced operation. The span is a child of the span in the statement
+// context, so queries must be issued with db.WithContext(ctx).
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	tracer := otel.Tracer("go-server/gorm")
+	cb := db.Callback()
+
+	befores := []error{
+		cb.Create().Before("gorm:create").Register("tracing:before_create", startSpan(tracer, "create")),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", startSpan(tracer, "query")),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", startSpan(tracer, "update")),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", startSpan(tracer, "delete")),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", startSpan(tracer, "row")),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", startSpan(tracer, "raw")),
+	}
+	afters := []error{
+		cb.Create().After("gorm:create").Register("tracing:after_create", endSpan),
+		cb.Query().After("gorm:query").Register("tracing:after_query", endSpan),
+		cb.Update().After("gorm:update").Register("tracing:after_update", endSpan),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", endSpan),
+		cb.Row().After("gorm:row").Register("tracing:after_row", endSpan),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", endSpan),
+	}
+	for _, err := range append(befores, afters...) {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startSpan(tracer trace.Tracer, operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		// Queries outside a traced operation, such as the job queue's
+		// polling, would each become a trace of their own
+		ctx := db.Statement.Context
+		if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+			return
+		}
+		_, span := tracer.Start(ctx, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemPostgreSQL,
+				semconv.DBOperationName(operation),
+			),
+		)
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+func endSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	if table := db.Statement.Table; table != "" {
+		span.SetAttributes(semconv.DBCollectionName(table))
+	}
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}